@@ -0,0 +1,226 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/config"
+)
+
+// completionFlag is a flag as seen by the completion generators.
+type completionFlag struct {
+	name    string
+	usage   string
+	isBool  bool
+	choices []string
+}
+
+// completionFlags returns the flags of c sorted by name.
+func completionFlags(c *command) []completionFlag {
+	var out []completionFlag
+	newFlagSet(c, config.Default()).VisitAll(func(f *flag.Flag) {
+		isBool := false
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok {
+			isBool = b.IsBoolFlag()
+		}
+		out = append(out, completionFlag{
+			name:    f.Name,
+			usage:   f.Usage,
+			isBool:  isBool,
+			choices: flagChoices(f.Usage),
+		})
+	})
+	return out
+}
+
+// positionalWords returns the fixed positional values of c: the choices
+// listed in its synopsis (e.g. "bash|zsh|fish"), or command names for help.
+// Returns nil when the command takes paths.
+func positionalWords(c *command) []string {
+	if c.name == "help" {
+		return commandNames()
+	}
+	if !strings.Contains(c.args, "|") {
+		return nil
+	}
+	return strings.Split(strings.Trim(c.args, "[]"), "|")
+}
+
+func runCompletion(_ *config.Config, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected one shell name: bash, zsh or fish")
+	}
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(os.Stdout)
+	case "zsh":
+		writeZshCompletion(os.Stdout)
+	case "fish":
+		writeFishCompletion(os.Stdout)
+	default:
+		return fmt.Errorf("unsupported shell %q (want bash, zsh or fish)", args[0])
+	}
+	return nil
+}
+
+func commandNames() []string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.name
+	}
+	return names
+}
+
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprint(w, `# bash completion for repogo
+_repogo() {
+    local cur prev cmd flags words
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+    cmd="${COMP_WORDS[1]}"
+`)
+	fmt.Fprint(w, "    if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then\n")
+	fmt.Fprintf(w, "        COMPREPLY=( $(compgen -W %q -- \"$cur\") $(compgen -f -- \"$cur\") )\n", strings.Join(commandNames(), " "))
+	fmt.Fprint(w, "        return\n    fi\n")
+
+	// Values of flags with a fixed set of choices.
+	seen := map[string]bool{}
+	fmt.Fprint(w, "    case \"$prev\" in\n")
+	for _, c := range commands {
+		for _, f := range completionFlags(c) {
+			if len(f.choices) == 0 || seen[f.name] {
+				continue
+			}
+			seen[f.name] = true
+			fmt.Fprintf(w, "        -%s) COMPREPLY=( $(compgen -W %q -- \"$cur\") ); return ;;\n", f.name, strings.Join(f.choices, " "))
+		}
+	}
+	fmt.Fprint(w, "    esac\n")
+
+	// Flags and positional words per command; anything else is "pack".
+	fmt.Fprint(w, "    case \"$cmd\" in\n")
+	var packFlags string
+	for _, c := range commands {
+		var names []string
+		for _, f := range completionFlags(c) {
+			names = append(names, "-"+f.name)
+		}
+		if c.name == "pack" {
+			packFlags = strings.Join(names, " ")
+			continue
+		}
+		fmt.Fprintf(w, "        %s) flags=%q", c.name, strings.Join(names, " "))
+		if words := positionalWords(c); len(words) > 0 {
+			fmt.Fprintf(w, "; words=%q", strings.Join(words, " "))
+		}
+		fmt.Fprint(w, " ;;\n")
+	}
+	fmt.Fprintf(w, "        *) flags=%q ;;\n    esac\n", packFlags)
+	fmt.Fprint(w, `    if [[ $cur == -* ]]; then
+        COMPREPLY=( $(compgen -W "$flags" -- "$cur") )
+    elif [[ -n $words ]]; then
+        COMPREPLY=( $(compgen -W "$words" -- "$cur") )
+    else
+        COMPREPLY=( $(compgen -f -- "$cur") )
+    fi
+}
+complete -o default -F _repogo repogo
+`)
+}
+
+// zshEscape escapes characters that are special inside _arguments specs.
+func zshEscape(s string) string {
+	r := strings.NewReplacer(`'`, `'\''`, `[`, `\[`, `]`, `\]`, `:`, `\:`)
+	return r.Replace(s)
+}
+
+func writeZshCompletion(w io.Writer) {
+	fmt.Fprint(w, "#compdef repogo\n\n_repogo() {\n    local -a commands\n    commands=(\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "        '%s:%s'\n", c.name, zshEscape(c.summary))
+	}
+	fmt.Fprint(w, `    )
+    if (( CURRENT == 2 )) && [[ $words[2] != -* ]]; then
+        _describe -t commands 'repogo command' commands
+        _files
+        return
+    fi
+    local cmd=$words[2]
+    if (( ${commands[(I)$cmd:*]} )); then
+        shift words
+        (( CURRENT-- ))
+    else
+        cmd=pack
+    fi
+    case $cmd in
+`)
+	for _, c := range commands {
+		fmt.Fprintf(w, "        %s)\n            _arguments", c.name)
+		for _, f := range completionFlags(c) {
+			spec := fmt.Sprintf("-%s[%s]", f.name, zshEscape(f.usage))
+			switch {
+			case f.isBool:
+			case len(f.choices) > 0:
+				spec += fmt.Sprintf(":%s:(%s)", f.name, strings.Join(f.choices, " "))
+			default:
+				spec += fmt.Sprintf(":%s:_files", f.name)
+			}
+			fmt.Fprintf(w, " \\\n                '%s'", spec)
+		}
+		switch words := positionalWords(c); {
+		case len(words) > 0:
+			fmt.Fprintf(w, " \\\n                '1:argument:(%s)'", strings.Join(words, " "))
+		case c.args != "":
+			fmt.Fprint(w, " \\\n                '*:path:_files'")
+		}
+		fmt.Fprint(w, "\n            ;;\n")
+	}
+	fmt.Fprint(w, `    esac
+}
+
+if [ "$funcstack[1]" = "_repogo" ]; then
+    _repogo "$@"
+else
+    compdef _repogo repogo
+fi
+`)
+}
+
+// fishEscape quotes s for use inside single quotes in fish.
+func fishEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+}
+
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprint(w, "# fish completion for repogo\ncomplete -c repogo -f\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "complete -c repogo -n __fish_use_subcommand -a %s -d '%s'\n", c.name, fishEscape(c.summary))
+	}
+	for _, c := range commands {
+		cond := fmt.Sprintf("'__fish_seen_subcommand_from %s'", c.name)
+		if c.name == "pack" {
+			// Pack flags also apply when no command is given.
+			cond = fmt.Sprintf("'__fish_use_subcommand; or __fish_seen_subcommand_from %s'", c.name)
+		}
+		for _, f := range completionFlags(c) {
+			line := fmt.Sprintf("complete -c repogo -n %s -o %s -d '%s'", cond, f.name, fishEscape(f.usage))
+			switch {
+			case f.isBool:
+			case len(f.choices) > 0:
+				line += fmt.Sprintf(" -x -a '%s'", strings.Join(f.choices, " "))
+			default:
+				line += " -r -F"
+			}
+			fmt.Fprintln(w, line)
+		}
+		switch words := positionalWords(c); {
+		case len(words) > 0:
+			fmt.Fprintf(w, "complete -c repogo -n %s -a '%s'\n", cond, strings.Join(words, " "))
+		case c.args != "":
+			fmt.Fprintf(w, "complete -c repogo -n %s -F\n", cond)
+		}
+	}
+}
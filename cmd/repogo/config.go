@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/config"
+)
+
+// Flags of the config command.
+var (
+	configGlobal bool
+	configForce  bool
+)
+
+// runConfig shows, locates or creates the configuration file.
+func runConfig(_ *config.Config, args []string) error {
+	action := "show"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	switch action {
+	case "path":
+		if p := config.FilePath(); p != "" {
+			fmt.Println(p)
+			return nil
+		}
+		fmt.Printf("no configuration file (looked for ./%s and %s)\n", config.FileName, config.GlobalFilePath())
+		return nil
+	case "show":
+		fs, _ := allFlags()
+		if err := config.ApplyFile(fs); err != nil {
+			return err
+		}
+		return printJSON(flagValues(fs))
+	case "init":
+		return initConfigFile()
+	default:
+		return fmt.Errorf("unknown action %q (want show, path or init)", action)
+	}
+}
+
+// allFlags returns a flag set holding the flags of every command.
+func allFlags() (*flag.FlagSet, *config.Config) {
+	cfg := config.Default()
+	fs := flag.NewFlagSet("repogo", flag.ContinueOnError)
+	for _, c := range commands {
+		if c.flags == nil || c.name == "config" {
+			continue
+		}
+		sub := flag.NewFlagSet(c.name, flag.ContinueOnError)
+		c.flags(sub, cfg)
+		sub.VisitAll(func(f *flag.Flag) {
+			if fs.Lookup(f.Name) == nil {
+				fs.Var(f.Value, f.Name, f.Usage)
+			}
+		})
+	}
+	return fs, cfg
+}
+
+// flagValues returns the typed value of every flag in fs, keyed by name.
+func flagValues(fs *flag.FlagSet) map[string]any {
+	values := map[string]any{}
+	fs.VisitAll(func(f *flag.Flag) {
+		if g, ok := f.Value.(flag.Getter); ok {
+			values[f.Name] = g.Get()
+		} else {
+			values[f.Name] = f.Value.String()
+		}
+	})
+	return values
+}
+
+func initConfigFile() error {
+	path := config.FileName
+	if configGlobal {
+		path = config.GlobalFilePath()
+		if path == "" {
+			return fmt.Errorf("cannot determine the user configuration directory")
+		}
+	}
+	if _, err := os.Stat(path); err == nil && !configForce {
+		return fmt.Errorf("%s already exists (use -force to overwrite)", path)
+	}
+	fs, _ := allFlags()
+	values := flagValues(fs)
+	// Output location is per invocation rather than a sensible default.
+	delete(values, "o")
+
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s (%d settings)\n", path, len(values))
+	return nil
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -3,149 +3,335 @@
 package main
 
 import (
-	"bytes"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
 	"strings"
 
-	"github.com/AndersonTsaiTW/RepoGo/internal/analyzer"
 	"github.com/AndersonTsaiTW/RepoGo/internal/config"
-	"github.com/AndersonTsaiTW/RepoGo/internal/git"
-	"github.com/AndersonTsaiTW/RepoGo/internal/models"
-	"github.com/AndersonTsaiTW/RepoGo/internal/renderer"
-	"github.com/AndersonTsaiTW/RepoGo/internal/scanner"
 )
 
-func usage() {
-	fmt.Fprintf(os.Stderr, `repogo %s
-
-Usage:
-  repogo [paths...] [flags]
-
-Examples:
-  repogo .
-  repogo src main.go
-  repogo . -o context.md
-  repogo . --include "*.go,*.md" --exclude "*_test.go,vendor"
-
-Flags:
-`, config.Version)
-	flag.PrintDefaults()
+// command describes a RepoGo subcommand. Flags, help text and shell
+// completion scripts are all derived from these definitions.
+type command struct {
+	name     string
+	args     string // synopsis of the positional arguments
+	summary  string
+	examples []string
+	flags    func(fs *flag.FlagSet, cfg *config.Config)
+	run      func(cfg *config.Config, args []string) error
 }
 
-func main() {
-	flag.Usage = usage
-	cfg := config.ParseFlags()
+// commands lists every subcommand; it is filled in init because the help and
+// completion commands refer back to it.
+var commands []*command
 
-	if *cfg.Help {
-		usage()
-		return
+func init() {
+	commands = []*command{
+		{
+			name:    "pack",
+			args:    "[paths...]",
+			summary: "Pack files into a single Markdown or JSON document (default command)",
+			examples: []string{
+				"repogo pack .",
+				"repogo src main.go",
+				"git diff --name-only main | repogo -files-from -",
+				"repogo . -o context.md",
+				`repogo . -include "*.go,*.md" -exclude "*_test.go,vendor"`,
+			},
+			flags: func(fs *flag.FlagSet, cfg *config.Config) {
+				cfg.RegisterOutput(fs)
+				cfg.RegisterScan(fs)
+				cfg.RegisterFilesFrom(fs)
+				cfg.RegisterImports(fs)
+				cfg.RegisterGrep(fs)
+				cfg.RegisterPack(fs)
+				cfg.RegisterHistory(fs)
+				cfg.RegisterLog(fs)
+				cfg.RegisterTree(fs)
+				cfg.RegisterExplain(fs)
+			},
+			run: runPack,
+		},
+		{
+			name:     "tree",
+			args:     "[paths...]",
+			summary:  "Print the directory tree, optionally annotated with sizes and tokens",
+			examples: []string{"repogo tree -tree-annotate -tree-depth 2 -tree-style unicode ."},
+			flags: func(fs *flag.FlagSet, cfg *config.Config) {
+				cfg.RegisterOutput(fs)
+				cfg.RegisterScan(fs)
+				cfg.RegisterFilesFrom(fs)
+				cfg.RegisterTree(fs)
+			},
+			run: runTree,
+		},
+		{
+			name:     "stats",
+			args:     "[paths...]",
+			summary:  "Report files, lines, bytes and tokens by language and directory",
+			examples: []string{"repogo stats .", "repogo stats -format json .", "repogo stats -history-window 90d"},
+			flags: func(fs *flag.FlagSet, cfg *config.Config) {
+				cfg.RegisterOutput(fs)
+				cfg.RegisterScan(fs)
+				cfg.RegisterFilesFrom(fs)
+				fs.StringVar(&cfg.HistoryWindow, "history-window", cfg.HistoryWindow, "count hotspot commits since this duration ago or date, e.g. 90d (default all history)")
+			},
+			run: runStats,
+		},
+		{
+			name:    "explain",
+			args:    "[paths...]",
+			summary: "List every candidate path with the decision taken and the rule responsible",
+			examples: []string{
+				"repogo explain .",
+				`repogo explain -exclude "*_test.go" -max-tokens 20000 .`,
+				"repogo explain -format json . | jq '.decisions[] | select(.decision == \"excluded\")'",
+				"repogo . -max-tokens 20000 -explain",
+			},
+			flags: func(fs *flag.FlagSet, cfg *config.Config) {
+				cfg.RegisterOutput(fs)
+				cfg.RegisterScan(fs)
+				cfg.RegisterFilesFrom(fs)
+				cfg.RegisterImports(fs)
+				cfg.RegisterGrep(fs)
+				cfg.RegisterPack(fs)
+				cfg.RegisterHistory(fs)
+			},
+			run: runExplain,
+		},
+		{
+			name:     "diff",
+			args:     "[dir]",
+			summary:  "Pack the files changed since a git revision together with the patch",
+			examples: []string{"repogo diff", "repogo diff -base main", "repogo diff -staged -o review.md"},
+			flags: func(fs *flag.FlagSet, cfg *config.Config) {
+				cfg.RegisterOutput(fs)
+				cfg.RegisterScan(fs)
+				cfg.RegisterGrep(fs)
+				cfg.RegisterPack(fs)
+				cfg.RegisterHistory(fs)
+				cfg.RegisterLog(fs)
+				cfg.RegisterTree(fs)
+				cfg.RegisterDiff(fs)
+			},
+			run: runDiff,
+		},
+		{
+			name:    "serve",
+			summary: "Serve packing, tree and file access over an HTTP API",
+			examples: []string{
+				"repogo serve -addr 127.0.0.1:9000",
+				`repogo serve -roots ~/src/app,~/src/lib -exclude ".git,node_modules"`,
+				`curl -d '{"paths":["internal"],"format":"json"}' localhost:8080/pack`,
+			},
+			flags: func(fs *flag.FlagSet, cfg *config.Config) {
+				cfg.RegisterServe(fs)
+				cfg.RegisterScan(fs)
+			},
+			run: runServe,
+		},
+		{
+			name:    "mcp",
+			args:    "[dir]",
+			summary: "Serve the Model Context Protocol over stdio for LLM agents",
+			examples: []string{
+				"repogo mcp",
+				`repogo mcp ~/src/app -exclude ".git,node_modules" -max-tokens 50000`,
+			},
+			flags: func(fs *flag.FlagSet, cfg *config.Config) {
+				cfg.RegisterScan(fs)
+				fs.IntVar(&cfg.MaxTokens, "max-tokens", cfg.MaxTokens, "default token budget of the pack tool (0 = no limit)")
+			},
+			run: runMCP,
+		},
+		{
+			name:     "presets",
+			args:     "[dir]",
+			summary:  "List the ecosystem presets and which ones -preset auto detects",
+			examples: []string{"repogo presets", "repogo presets -format json ~/src/app"},
+			flags: func(fs *flag.FlagSet, cfg *config.Config) {
+				cfg.RegisterOutput(fs)
+			},
+			run: runPresets,
+		},
+		{
+			name:    "config",
+			args:    "[show|path|init]",
+			summary: "Show, locate or create the configuration file",
+			examples: []string{
+				"repogo config show",
+				"repogo config init",
+				"repogo config init -global",
+			},
+			flags: func(fs *flag.FlagSet, cfg *config.Config) {
+				fs.BoolVar(&configGlobal, "global", false, "init: write the per-user file instead of ./"+config.FileName)
+				fs.BoolVar(&configForce, "force", false, "init: overwrite an existing file")
+			},
+			run: runConfig,
+		},
+		{
+			name:     "completion",
+			args:     "bash|zsh|fish",
+			summary:  "Generate a shell completion script",
+			examples: []string{"source <(repogo completion bash)", "repogo completion fish > ~/.config/fish/completions/repogo.fish"},
+			run:      runCompletion,
+		},
+		{
+			name:    "version",
+			summary: "Print the version",
+			run: func(cfg *config.Config, args []string) error {
+				fmt.Println("repogo", config.Version)
+				return nil
+			},
+		},
+		{
+			name:    "help",
+			args:    "[command]",
+			summary: "Show help for a command",
+			run:     runHelp,
+		},
 	}
-	if *cfg.Version {
-		fmt.Println("repogo", config.Version)
-		return
+}
+
+func findCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
 	}
+	return nil
+}
 
-	paths := config.Args()
-	if len(paths) == 0 {
-		paths = []string{"."}
+// newFlagSet creates the flag set of c bound to cfg.
+func newFlagSet(c *command, cfg *config.Config) *flag.FlagSet {
+	fs := flag.NewFlagSet("repogo "+c.name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if c.flags != nil {
+		c.flags(fs, cfg)
 	}
+	return fs
+}
 
-	rootAbs, _ := scanner.ResolveRoot(paths)
+func usage(w io.Writer) {
+	fmt.Fprintf(w, `repogo %s
 
-	doc := models.OutputDoc{Location: rootAbs}
-	if gi, err := git.GetInfo(rootAbs); err == nil {
-		doc.Git = gi
-	} // Otherwise leave empty, will output "Not a git repository" later
+Usage:
+  repogo <command> [arguments] [flags]
+  repogo [paths...] [flags]          (same as "repogo pack")
 
-	includes := scanner.SplitList(*cfg.Include)
-	excludes := scanner.SplitList(*cfg.Exclude)
+Commands:
+`, config.Version)
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-11s %s\n", c.name, c.summary)
+	}
+	fmt.Fprint(w, `
+Run "repogo help <command>" for the flags of a command.
+`)
+}
 
-	files, structure := scanner.CollectFiles(rootAbs, paths, includes, excludes)
-	doc.Structure = structure
+func commandUsage(w io.Writer, c *command) {
+	fmt.Fprintf(w, "%s\n\nUsage:\n  repogo %s", c.summary, c.name)
+	if c.args != "" {
+		fmt.Fprintf(w, " %s", c.args)
+	}
+	fs := newFlagSet(c, config.Default())
+	hasFlags := false
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		fmt.Fprint(w, " [flags]")
+	}
+	fmt.Fprintln(w)
+	if len(c.examples) > 0 {
+		fmt.Fprint(w, "\nExamples:\n")
+		for _, e := range c.examples {
+			fmt.Fprintf(w, "  %s\n", e)
+		}
+	}
+	if hasFlags {
+		fmt.Fprint(w, "\nFlags:\n")
+		fs.SetOutput(w)
+		fs.PrintDefaults()
+	}
+}
 
-	var totalTokens, totalLines, binaryCount, skippedByToken int
+func runHelp(_ *config.Config, args []string) error {
+	if len(args) == 0 {
+		usage(os.Stdout)
+		return nil
+	}
+	c := findCommand(args[0])
+	if c == nil {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	commandUsage(os.Stdout, c)
+	return nil
+}
 
-	for _, p := range files {
-		rel, _ := filepath.Rel(rootAbs, p)
-		if rel == "." {
-			continue
-		}
-		info, err := os.Stat(p)
-		if err != nil {
-			doc.Files = append(doc.Files, models.FileEntry{
-				Path:             filepath.ToSlash(rel),
-				ReadErrorMessage: err.Error(),
-			})
-			continue
-		}
-		if info.IsDir() {
-			continue
-		}
-		entry := models.FileEntry{
-			Path: filepath.ToSlash(rel),
-			Size: info.Size(),
-		}
+func main() {
+	os.Exit(execute(os.Args[1:]))
+}
 
-		f, err := os.Open(p)
-		if err != nil {
-			entry.ReadErrorMessage = err.Error()
-			doc.Files = append(doc.Files, entry)
-			continue
-		}
-		content, isBinary, truncated, lines := analyzer.ReadFileContent(f, *cfg.MaxFileSize)
-		_ = f.Close()
-		entry.IsBinary = isBinary
-		entry.Truncated = truncated
-
-		if !isBinary {
-			entry.Content = string(content)
-			entry.LanguageHint = analyzer.GuessLanguage(rel)
-			totalLines += lines
-		} else {
-			binaryCount++
+// execute dispatches args to a command and returns the process exit code.
+func execute(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "-h", "-help", "--help":
+			usage(os.Stdout)
+			return 0
+		case "-v", "-version", "--version":
+			fmt.Println("repogo", config.Version)
+			return 0
 		}
+	}
 
-		addTokens := analyzer.EstimateTokens(entry.Content)
-		if *cfg.MaxTokens > 0 && totalTokens+addTokens > *cfg.MaxTokens {
-			skippedByToken++
-			entry.Content = ""
-			entry.Truncated = true
-			entry.ReadErrorMessage = fmt.Sprintf("omitted due to --max-tokens budget (would add ~%d tokens)", addTokens)
-			doc.Files = append(doc.Files, entry)
-			break
+	// "repogo [paths...] [flags]" is an alias for "repogo pack".
+	c := findCommand("pack")
+	if len(args) > 0 {
+		if found := findCommand(args[0]); found != nil {
+			c, args = found, args[1:]
 		}
-		totalTokens += addTokens
-		doc.Files = append(doc.Files, entry)
 	}
 
-	doc.Summary = models.Summary{
-		TotalFiles:       len(doc.Files),
-		TotalLines:       totalLines,
-		EstimatedTokens:  totalTokens,
-		SkippedByLimit:   skippedByToken,
-		BinaryFilesCount: binaryCount,
+	cfg := config.Default()
+	fs := newFlagSet(c, cfg)
+	if err := config.ApplyFile(fs); err != nil {
+		fmt.Fprintf(os.Stderr, "config: %v\n", err)
+		return 1
 	}
-
-	var out bytes.Buffer
-	switch strings.ToLower(*cfg.Format) {
-	case "json":
-		_ = renderer.RenderJSON(&out, doc)
-	default:
-		renderer.RenderMarkdown(&out, doc)
+	positional, err := config.Parse(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		commandUsage(os.Stdout, c)
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "repogo %s: %v\n\n", c.name, err)
+		commandUsage(os.Stderr, c)
+		return 2
 	}
-	if *cfg.ShowTokens {
-		fmt.Fprintf(&out, "\nEstimated tokens: %d\n", doc.Summary.EstimatedTokens)
+	if err := c.run(cfg, positional); err != nil {
+		fmt.Fprintf(os.Stderr, "repogo %s: %v\n", c.name, err)
+		return 1
 	}
+	return 0
+}
 
-	if *cfg.Output == "" {
-		_, _ = os.Stdout.Write(out.Bytes())
-	} else {
-		if err := os.WriteFile(*cfg.Output, out.Bytes(), 0644); err != nil {
-			fmt.Fprintf(os.Stderr, "write output: %v\n", err)
-			os.Exit(1)
-		}
+// flagChoices extracts the allowed values from a usage string such as
+// "output format: markdown|json".
+func flagChoices(usage string) []string {
+	i := strings.LastIndex(usage, ": ")
+	if i < 0 {
+		return nil
+	}
+	fields := strings.Fields(usage[i+2:])
+	if len(fields) == 0 {
+		return nil
+	}
+	choices := strings.Split(fields[0], "|")
+	if len(choices) < 2 {
+		return nil
 	}
+	return choices
 }
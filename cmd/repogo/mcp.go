@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/config"
+	"github.com/AndersonTsaiTW/RepoGo/internal/mcp"
+)
+
+// runMCP serves the Model Context Protocol on stdin and stdout.
+func runMCP(cfg *config.Config, args []string) error {
+	root := "."
+	switch len(args) {
+	case 0:
+	case 1:
+		root = args[0]
+	default:
+		return fmt.Errorf("expected at most one directory, got %d", len(args))
+	}
+	opts, err := packOptions(cfg, nil)
+	if err != nil {
+		return err
+	}
+	srv, err := mcp.New(root, opts)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return srv.Serve(ctx, os.Stdin, os.Stdout)
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/config"
+	"github.com/AndersonTsaiTW/RepoGo/internal/git"
+	"github.com/AndersonTsaiTW/RepoGo/internal/renderer"
+	"github.com/AndersonTsaiTW/RepoGo/internal/scanner"
+	"github.com/AndersonTsaiTW/RepoGo/repogo"
+)
+
+// runPack packs the given paths into a single document.
+func runPack(cfg *config.Config, args []string) error {
+	if cfg.Explain {
+		return runExplain(cfg, args)
+	}
+	opts, err := packOptions(cfg, args)
+	if err != nil {
+		return err
+	}
+	doc, err := repogo.Pack(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+	return writeDoc(cfg, doc)
+}
+
+// runStats reports per-language and per-directory statistics.
+func runStats(cfg *config.Config, args []string) error {
+	opts, err := packOptions(cfg, args)
+	if err != nil {
+		return err
+	}
+	opts.Stats = true
+	opts.History = true
+	doc, err := repogo.Pack(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	switch strings.ToLower(cfg.Format) {
+	case "json":
+		_ = renderer.RenderStatsJSON(&out, *doc)
+	default:
+		renderer.RenderStatsMarkdown(&out, *doc)
+	}
+	return writeOutput(cfg, out.Bytes())
+}
+
+// runExplain lists the decision taken for every candidate path.
+func runExplain(cfg *config.Config, args []string) error {
+	opts, err := packOptions(cfg, args)
+	if err != nil {
+		return err
+	}
+	e, err := repogo.Explain(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	switch strings.ToLower(cfg.Format) {
+	case "json":
+		_ = renderer.RenderExplainJSON(&out, *e)
+	default:
+		renderer.RenderExplainMarkdown(&out, *e)
+	}
+	return writeOutput(cfg, out.Bytes())
+}
+
+// runTree prints the directory tree of the given paths.
+func runTree(cfg *config.Config, args []string) error {
+	opts, err := packOptions(cfg, args)
+	if err != nil {
+		return err
+	}
+	tree, err := repogo.Tree(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	switch strings.ToLower(cfg.Format) {
+	case "json":
+		_ = renderer.RenderTreeJSON(&out, tree)
+	default:
+		out.WriteString(scanner.RenderTree(tree, opts.Tree))
+	}
+	return writeOutput(cfg, out.Bytes())
+}
+
+// runDiff packs the files changed since a git revision together with the patch.
+func runDiff(cfg *config.Config, args []string) error {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	opts, err := packOptions(cfg, nil)
+	if err != nil {
+		return err
+	}
+	root, err := git.TopLevel(dir)
+	if err != nil {
+		return fmt.Errorf("%s: %w", dir, err)
+	}
+	changed, err := git.ChangedFiles(root, cfg.DiffBase, cfg.DiffStaged)
+	if err != nil {
+		return err
+	}
+	patch, err := git.Diff(root, cfg.DiffBase, cfg.DiffStaged)
+	if err != nil {
+		return err
+	}
+
+	var doc *repogo.OutputDoc
+	if len(changed) == 0 {
+		doc = &repogo.OutputDoc{Location: root, Structure: "```\n```"}
+		if gi, err := git.GetInfo(root); err == nil {
+			doc.Git = gi
+		}
+	} else {
+		opts.Root = root
+		opts.Paths = make([]string, 0, len(changed))
+		for _, rel := range changed {
+			opts.Paths = append(opts.Paths, filepath.Join(root, filepath.FromSlash(rel)))
+		}
+		if doc, err = repogo.Pack(context.Background(), opts); err != nil {
+			return err
+		}
+	}
+	doc.Diff = patch
+	return writeDoc(cfg, doc)
+}
+
+// packOptions translates the command-line configuration into library options
+// and loads any extra language definitions.
+func packOptions(cfg *config.Config, args []string) (repogo.Options, error) {
+	if cfg.GrepMode != "any" && cfg.GrepMode != "all" {
+		return repogo.Options{}, fmt.Errorf("invalid -grep-mode %q (want any or all)", cfg.GrepMode)
+	}
+	since, err := config.ParseTime(cfg.HistoryWindow, time.Now())
+	if err != nil {
+		return repogo.Options{}, fmt.Errorf("-history-window: %w", err)
+	}
+	newer, err := config.ParseTime(cfg.NewerThan, time.Now())
+	if err != nil {
+		return repogo.Options{}, fmt.Errorf("-newer-than: %w", err)
+	}
+	older, err := config.ParseTime(cfg.OlderThan, time.Now())
+	if err != nil {
+		return repogo.Options{}, fmt.Errorf("-older-than: %w", err)
+	}
+	minSize, err := config.ParseSize(cfg.MinSize)
+	if err != nil {
+		return repogo.Options{}, fmt.Errorf("-min-size: %w", err)
+	}
+	maxSize, err := config.ParseSize(cfg.MaxSize)
+	if err != nil {
+		return repogo.Options{}, fmt.Errorf("-max-size: %w", err)
+	}
+	if cfg.Query != "" && cfg.Sort == repogo.SortChurn {
+		return repogo.Options{}, fmt.Errorf("-sort churn cannot be combined with -query")
+	}
+	if cfg.DedupeThreshold < 0 || cfg.DedupeThreshold > 1 {
+		return repogo.Options{}, fmt.Errorf("invalid -dedupe-threshold %v (want a value from 0 to 1)", cfg.DedupeThreshold)
+	}
+	var files []string
+	if cfg.FilesFrom != "" {
+		if len(args) > 1 {
+			return repogo.Options{}, fmt.Errorf("-files-from takes at most one directory argument, the root")
+		}
+		if files, err = readFileList(cfg.FilesFrom); err != nil {
+			return repogo.Options{}, fmt.Errorf("-files-from: %w", err)
+		}
+	}
+	if cfg.Languages != "" {
+		if err := repogo.LoadLanguages(cfg.Languages); err != nil {
+			return repogo.Options{}, fmt.Errorf("load languages: %w", err)
+		}
+	}
+	opts := repogo.Options{
+		Paths:            args,
+		Files:            files,
+		Include:          scanner.SplitList(cfg.Include),
+		Exclude:          scanner.SplitList(cfg.Exclude),
+		Presets:          scanner.SplitList(cfg.Preset),
+		MaxFileSize:      cfg.MaxFileSize,
+		MaxTokens:        cfg.MaxTokens,
+		Dedupe:           cfg.Dedupe,
+		DedupeThreshold:  cfg.DedupeThreshold,
+		Compress:         cfg.Compress,
+		LineNumbers:      cfg.LineNumbers,
+		Stats:            cfg.Stats,
+		Query:            cfg.Query,
+		Submodules:       cfg.Submodules,
+		NestedGit:        cfg.NestedGit,
+		Symlinks:         cfg.Symlinks,
+		Generated:        cfg.Generated,
+		SymlinksOutside:  cfg.SymlinksOutside,
+		NewerThan:        newer,
+		OlderThan:        older,
+		MinSize:          minSize,
+		MaxSize:          maxSize,
+		MaxDepth:         cfg.MaxDepth,
+		Sort:             cfg.Sort,
+		History:          cfg.History,
+		HistorySince:     since,
+		SkipDependencies: !cfg.Deps,
+		Log:              cfg.Log,
+		LogStats:         cfg.LogStats,
+		LogPackedOnly:    cfg.LogPacked,
+		FollowImports:    scanner.SplitList(cfg.FollowImports),
+		ReverseDeps:      cfg.ReverseDeps,
+		ImportGraph:      cfg.ImportGraph,
+		Grep:             cfg.Grep,
+		GrepAll:          cfg.GrepMode == "all",
+		Snippets:         cfg.GrepContext >= 0,
+		ContextLines:     cfg.GrepContext,
+		Tree: repogo.TreeOptions{
+			Style:    cfg.TreeStyle,
+			MaxDepth: cfg.TreeDepth,
+			Annotate: cfg.TreeAnnotate,
+		},
+		OnWarning: func(path string, err error) {
+			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", path, err)
+		},
+	}
+	if files != nil {
+		opts.Paths = nil
+		if len(args) == 1 {
+			opts.Root = args[0]
+		}
+	}
+	return opts, nil
+}
+
+// readFileList reads the paths listed in name, or on stdin for "-". Paths
+// are separated by NUL bytes if the list contains any, as written by
+// `git ls-files -z` or `find -print0`, and by newlines otherwise.
+func readFileList(name string) ([]string, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sep := "\n"
+	if bytes.IndexByte(data, 0) >= 0 {
+		sep = "\x00"
+	}
+	files := []string{}
+	for _, p := range strings.Split(string(data), sep) {
+		if sep == "\n" {
+			p = strings.TrimSuffix(p, "\r")
+		}
+		if p != "" {
+			files = append(files, p)
+		}
+	}
+	return files, nil
+}
+
+// writeDoc renders doc in the configured format and writes it out.
+func writeDoc(cfg *config.Config, doc *repogo.OutputDoc) error {
+	var out bytes.Buffer
+	if err := repogo.Render(&out, strings.ToLower(cfg.Format), doc); err != nil {
+		return err
+	}
+	if cfg.ShowTokens {
+		fmt.Fprintf(&out, "\nEstimated tokens: %d\n", doc.Summary.EstimatedTokens)
+	}
+	return writeOutput(cfg, out.Bytes())
+}
+
+// writeOutput writes data to the -o file, or to stdout when none is set.
+func writeOutput(cfg *config.Config, data []byte) error {
+	if cfg.Output == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(cfg.Output, data, 0644); err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+	return nil
+}
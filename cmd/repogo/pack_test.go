@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadFileList(t *testing.T) {
+	tests := []struct {
+		name, content string
+		want          []string
+	}{
+		{"newlines", "a.go\nsub/b.go\n", []string{"a.go", "sub/b.go"}},
+		{"crlf", "a.go\r\nb.go\r\n\r\n", []string{"a.go", "b.go"}},
+		{"nul", "a b.go\x00line\nbreak.go\x00", []string{"a b.go", "line\nbreak.go"}},
+		{"empty", "", []string{}},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		p := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(p, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := readFileList(p)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("readFileList(%q) = %q, %v; want %q", tt.content, got, err, tt.want)
+		}
+	}
+	if _, err := readFileList(filepath.Join(dir, "missing")); err == nil {
+		t.Error("readFileList of a missing file succeeded")
+	}
+}
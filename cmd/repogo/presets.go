@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/config"
+	"github.com/AndersonTsaiTW/RepoGo/internal/scanner"
+)
+
+// runPresets lists the built-in presets, marking those detected in the
+// given directory.
+func runPresets(cfg *config.Config, args []string) error {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	detected := scanner.DetectPresets(root)
+
+	var out bytes.Buffer
+	switch strings.ToLower(cfg.Format) {
+	case "json":
+		type preset struct {
+			scanner.Preset
+			Detected bool `json:"detected"`
+		}
+		var list []preset
+		for _, p := range scanner.Presets() {
+			list = append(list, preset{p, slices.Contains(detected, p.Name)})
+		}
+		enc := json.NewEncoder(&out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(list); err != nil {
+			return err
+		}
+	default:
+		fmt.Fprint(&out, "| Preset | Description | Detected by | Excludes |\n")
+		fmt.Fprint(&out, "|--------|-------------|-------------|----------|\n")
+		for _, p := range scanner.Presets() {
+			name := p.Name
+			if slices.Contains(detected, p.Name) {
+				name += " (detected)"
+			}
+			fmt.Fprintf(&out, "| %s | %s | %s | %s |\n", name, p.Description,
+				codeList(p.Markers), codeList(p.Excludes))
+		}
+		fmt.Fprintf(&out, "\nUse -preset with a comma-separated list, or -preset %s to select the presets detected in the root.\n", scanner.PresetAuto)
+	}
+	return writeOutput(cfg, out.Bytes())
+}
+
+// codeList formats items as comma-separated inline code, or "–" if empty.
+func codeList(items []string) string {
+	if len(items) == 0 {
+		return "–"
+	}
+	return "`" + strings.Join(items, "`, `") + "`"
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/config"
+	"github.com/AndersonTsaiTW/RepoGo/internal/scanner"
+	"github.com/AndersonTsaiTW/RepoGo/internal/server"
+)
+
+// runServe serves the HTTP API until interrupted.
+func runServe(cfg *config.Config, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments %v (use -roots)", args)
+	}
+	opts, err := packOptions(cfg, nil)
+	if err != nil {
+		return err
+	}
+	srv, err := server.New(scanner.SplitList(cfg.ServeRoots), opts)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	hs := &http.Server{
+		Addr:              cfg.ServeAddr,
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- hs.ListenAndServe() }()
+	fmt.Fprintf(os.Stderr, "repogo: serving on %s\n", cfg.ServeAddr)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := hs.Shutdown(shutdown); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+// Package analyzer provides file content analysis functionality.
+package analyzer
+
+import "strings"
+
+// Compression levels, each including the ones before it: CompressWhitespace
+// trims trailing whitespace and squeezes runs of blank lines,
+// CompressComments also strips comments and CompressIndent also shrinks
+// indentation to one space per level.
+const (
+	CompressNone       = "none"
+	CompressWhitespace = "whitespace"
+	CompressComments   = "comments"
+	CompressIndent     = "indent"
+)
+
+var compressLevels = map[string]int{
+	CompressWhitespace: 1,
+	CompressComments:   2,
+	CompressIndent:     3,
+}
+
+// goDirectives are line comments that change how Go code builds.
+var goDirectives = []string{"//go:", "// +build", "//line ", "//export "}
+
+// Compress returns content reduced according to level, together with the
+// original line number of each line of the result. Files of unknown
+// language (a nil lang), diffs and Markdown, whose whitespace is
+// significant, are returned unchanged. Comment stripping follows lang's comment syntax and
+// skips strings between lang's quotes; it is a heuristic that prefers
+// keeping a comment to damaging code, and keeps shebang lines and Go build
+// directives.
+func Compress(content string, lang *Language, level string) (string, []int) {
+	n := compressLevels[level]
+	if lang == nil || n == 0 || lang.Name == "Diff" || lang.Name == "Markdown" || content == "" {
+		return content, nil
+	}
+	var lines []string
+	var origins []int
+	if n >= 2 && (len(lang.LineComments) > 0 || len(lang.BlockComments) > 0) {
+		lines, origins = stripComments(content, lang)
+	} else {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+		origins = make([]int, len(lines))
+		for i := range origins {
+			origins[i] = i + 1
+		}
+	}
+	if n >= 3 {
+		collapseIndent(lines, lang.Quotes)
+	}
+	lines, origins = squeezeWhitespace(lines, origins)
+	out := strings.Join(lines, "\n")
+	if len(lines) > 0 && strings.HasSuffix(content, "\n") {
+		out += "\n"
+	}
+	return out, origins
+}
+
+// squeezeWhitespace trims trailing whitespace from every line, drops blank
+// lines at either end and reduces other runs of blank lines to one.
+func squeezeWhitespace(lines []string, origins []int) ([]string, []int) {
+	var outLines []string
+	var outOrigins []int
+	blank, blankAt := false, 0
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t\r\f\v")
+		if line == "" {
+			if !blank && len(outLines) > 0 {
+				blank, blankAt = true, origins[i]
+			}
+			continue
+		}
+		if blank {
+			outLines = append(outLines, "")
+			outOrigins = append(outOrigins, blankAt)
+		}
+		blank = false
+		outLines = append(outLines, line)
+		outOrigins = append(outOrigins, origins[i])
+	}
+	return outLines, outOrigins
+}
+
+// collapseIndent replaces space indentation with one space per level. The
+// levels come from a stack of the indentation widths enclosing each line,
+// so a line is indented more than the line before it exactly when it was
+// before, and indentation-significant languages such as Python and YAML
+// keep their structure. Lines inside multi-line string literals delimited
+// by quotes keep their text, and tabs are already one character per level
+// and are left alone.
+func collapseIndent(lines []string, quotes []string) {
+	literal := literalLines(lines, quotes)
+	stack := []int{0}
+	for i, line := range lines {
+		if literal[i] || strings.TrimSpace(line) == "" {
+			continue
+		}
+		w := len(line) - len(strings.TrimLeft(line, " "))
+		for stack[len(stack)-1] > w {
+			stack = stack[:len(stack)-1]
+		}
+		if stack[len(stack)-1] < w {
+			stack = append(stack, w)
+		}
+		lines[i] = strings.Repeat(" ", len(stack)-1) + line[w:]
+	}
+}
+
+// literalLines reports which lines begin inside a string literal opened on
+// an earlier line.
+func literalLines(lines []string, quotes []string) []bool {
+	literal := make([]bool, len(lines))
+	text := strings.Join(lines, "\n")
+	n := 0 // current line
+	for i := 0; i < len(text); {
+		q := quoteAt(text[i:], quotes)
+		if q == "" {
+			if text[i] == '\n' {
+				n++
+			}
+			i++
+			continue
+		}
+		j := stringEnd(text[i:], q)
+		for k := 0; k < strings.Count(text[i:i+j], "\n"); k++ {
+			n++
+			literal[n] = true
+		}
+		i += j
+	}
+	return literal
+}
+
+// stripComments removes the comments of lang from content and returns the
+// remaining lines with their original line numbers. Lines left empty by
+// removing a comment are dropped; a block comment spanning lines leaves a
+// line break so that code on either side stays on its own line.
+func stripComments(content string, lang *Language) ([]string, []int) {
+	var lines []string
+	var origins []int
+	var line strings.Builder
+	lineNo, start := 1, 1 // current line, and where the pending output line began
+	removed := false      // whether a comment was cut from the pending line
+	flush := func() {
+		if !removed || strings.TrimSpace(line.String()) != "" {
+			lines = append(lines, line.String())
+			origins = append(origins, start)
+		}
+		line.Reset()
+		removed = false
+	}
+
+	i := 0
+	if strings.HasPrefix(content, "#!") {
+		end := strings.IndexByte(content, '\n')
+		if end < 0 {
+			end = len(content)
+		}
+		line.WriteString(content[:end])
+		i = end
+	}
+	for i < len(content) {
+		c := content[i]
+		if c == '\n' {
+			flush()
+			i++
+			lineNo++
+			start = lineNo
+			continue
+		}
+		rest := content[i:]
+		if open, end, ok := blockStart(rest, lang.BlockComments); ok {
+			j := strings.Index(rest[len(open):], end)
+			comment := rest
+			if j >= 0 {
+				comment = rest[:len(open)+j+len(end)]
+			}
+			i += len(comment)
+			removed = true
+			if n := strings.Count(comment, "\n"); n > 0 {
+				flush()
+				lineNo += n
+				start = lineNo
+				removed = true
+			} else if i < len(content) && !isSpace(content[i]) && line.Len() > 0 && !isSpace(lastByte(&line)) {
+				line.WriteByte(' ') // keep the tokens on either side apart
+			}
+			continue
+		}
+		if isLineComment(content, i, lang) {
+			j := strings.IndexByte(rest, '\n')
+			if j < 0 {
+				j = len(rest)
+			}
+			trimmed := strings.TrimRight(line.String(), " \t")
+			line.Reset()
+			line.WriteString(trimmed)
+			i += j
+			removed = true
+			continue
+		}
+		if q := quoteAt(rest, lang.Quotes); q != "" {
+			j := stringEnd(rest, q)
+			lit := rest[:j]
+			for {
+				k := strings.IndexByte(lit, '\n')
+				if k < 0 {
+					break
+				}
+				line.WriteString(lit[:k])
+				flush()
+				lineNo++
+				start = lineNo
+				lit = lit[k+1:]
+			}
+			line.WriteString(lit)
+			i += j
+			continue
+		}
+		line.WriteByte(c)
+		i++
+	}
+	if line.Len() > 0 || removed {
+		flush()
+	}
+	return lines, origins
+}
+
+// isLineComment reports whether a line comment of lang starts at content[i].
+// Single-character markers such as "#" or ";" must start a word, so that
+// "$#" or "a;b" are kept, and markers that double as quotes ("'" in Visual
+// Basic, '"' in Vim script) only count at the start of a line. A preceding
+// backslash escapes any marker, and "//" after a colon is taken for a URL.
+func isLineComment(content string, i int, lang *Language) bool {
+	rest := content[i:]
+	var prev byte = '\n'
+	if i > 0 {
+		prev = content[i-1]
+	}
+	for _, m := range lang.LineComments {
+		if !strings.HasPrefix(rest, m) || prev == '\\' {
+			continue
+		}
+		switch {
+		case m == "//" || m == "--":
+			if m == "//" && prev == ':' {
+				continue
+			}
+		case m == "'" || m == `"`:
+			start := strings.LastIndexByte(content[:i], '\n') + 1
+			if strings.TrimSpace(content[start:i]) != "" {
+				continue
+			}
+		case len(m) == 1:
+			if !isSpace(prev) || (m == "#" && strings.HasPrefix(rest, "#[")) {
+				continue
+			}
+		}
+		if m == "//" && lang.Name == "Go" && hasPrefixAny(rest, goDirectives) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// quoteAt returns the longest of quotes starting s, if any; with no quotes
+// it looks for double quotes.
+func quoteAt(s string, quotes []string) string {
+	if len(quotes) == 0 {
+		quotes = doubleQuote
+	}
+	match := ""
+	for _, q := range quotes {
+		if len(q) > len(match) && strings.HasPrefix(s, q) {
+			match = q
+		}
+	}
+	return match
+}
+
+// stringEnd returns the length of the string literal opened by q at the
+// start of s. Single and double quoted strings end at the line break if
+// unterminated and honour backslash escapes; triple-quoted and backquoted
+// strings may span lines.
+func stringEnd(s string, q string) int {
+	multiline := len(q) == 3 || q == "`"
+	for i := len(q); i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && q != "`":
+			i++
+		case s[i] == '\n' && !multiline:
+			return i
+		case strings.HasPrefix(s[i:], q):
+			return i + len(q)
+		}
+	}
+	return len(s)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func lastByte(b *strings.Builder) byte {
+	s := b.String()
+	return s[len(s)-1]
+}
@@ -0,0 +1,163 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	tests := []struct {
+		name  string
+		lang  string
+		level string
+		in    string
+		want  string
+	}{
+		{
+			name:  "none",
+			lang:  "Go",
+			level: CompressNone,
+			in:    "package a  \n\n\n// doc\nfunc A() {}\n",
+			want:  "package a  \n\n\n// doc\nfunc A() {}\n",
+		},
+		{
+			name:  "whitespace",
+			lang:  "Go",
+			level: CompressWhitespace,
+			in:    "\n\npackage a  \n\n\n\n// doc\nfunc A() {}\t\n\n",
+			want:  "package a\n\n// doc\nfunc A() {}\n",
+		},
+		{
+			name:  "Go comments",
+			lang:  "Go",
+			level: CompressComments,
+			in:    "//go:build linux\n\n// Package a does things.\npackage a\n\n/* block\n   comment */\nvar s = \"// not a comment\" // trailing\nvar r = `/* raw\n// string */`\nvar c = '\"' // rune\nfunc A() { /* inline */ }\n",
+			want:  "//go:build linux\n\npackage a\n\nvar s = \"// not a comment\"\nvar r = `/* raw\n// string */`\nvar c = '\"'\nfunc A() {  }\n",
+		},
+		{
+			name:  "Python comments",
+			lang:  "Python",
+			level: CompressComments,
+			in:    "#!/usr/bin/env python3\n# module comment\ns = '# kept'  # dropped\nt = \"\"\"\n# kept too\n\"\"\"\n",
+			want:  "#!/usr/bin/env python3\ns = '# kept'\nt = \"\"\"\n# kept too\n\"\"\"\n",
+		},
+		{
+			// ' quotes symbols in Lisp; taking it for a string would end the
+			// string in "it's" and strip the rest of the literal.
+			name:  "Clojure quotes",
+			lang:  "Clojure",
+			level: CompressComments,
+			in:    "(def xs '(a b)) ; list\n(println 'x \"it's ; not a comment\")\n",
+			want:  "(def xs '(a b))\n(println 'x \"it's ; not a comment\")\n",
+		},
+		{
+			// Rust lifetimes are not character literals.
+			name:  "Rust lifetimes",
+			lang:  "Rust",
+			level: CompressComments,
+			in:    "fn f<'a>(s: &'a str) -> &'a str { s } // id\nlet u = \"http://x\"; // url\n",
+			want:  "fn f<'a>(s: &'a str) -> &'a str { s }\nlet u = \"http://x\";\n",
+		},
+		{
+			name:  "shell",
+			lang:  "Shell",
+			level: CompressComments,
+			in:    "echo $# 'a # b' # count\n# comment\necho \"${x#prefix}\"\n",
+			want:  "echo $# 'a # b'\necho \"${x#prefix}\"\n",
+		},
+		{
+			name:  "SQL",
+			lang:  "SQL",
+			level: CompressComments,
+			in:    "SELECT '--' AS dashes -- comment\nFROM t /* block */ WHERE a = 1;\n",
+			want:  "SELECT '--' AS dashes\nFROM t  WHERE a = 1;\n",
+		},
+		{
+			name:  "HTML",
+			lang:  "HTML",
+			level: CompressComments,
+			in:    "<p title=\"<!-- kept -->\">Hi</p>\n<!--\n  note\n-->\n<br>\n",
+			want:  "<p title=\"<!-- kept -->\">Hi</p>\n<br>\n",
+		},
+		{
+			name:  "Go indent",
+			lang:  "Go",
+			level: CompressIndent,
+			in:    "func A() {\n\tif x {\n\t\treturn\n\t}\n}\n",
+			want:  "func A() {\n\tif x {\n\t\treturn\n\t}\n}\n",
+		},
+		{
+			name:  "Python indent",
+			lang:  "Python",
+			level: CompressIndent,
+			in:    "def f(a,\n      b):\n    if a:\n        return b  # why\n    return a\n",
+			want:  "def f(a,\n b):\n if a:\n  return b\n return a\n",
+		},
+		{
+			// Steps of 4 and 2 in one file: every line must keep its
+			// nesting relative to the lines around it.
+			name:  "YAML indent",
+			lang:  "YAML",
+			level: CompressIndent,
+			in:    "a:\n    b: 1\n    c:\n      d:\n        e: 1\n    f:\n        g: 1\n    h:\n        i: 1\n",
+			want:  "a:\n b: 1\n c:\n  d:\n   e: 1\n f:\n  g: 1\n h:\n  i: 1\n",
+		},
+		{
+			name:  "Python indent inside a docstring",
+			lang:  "Python",
+			level: CompressIndent,
+			in:    "def f():\n    \"\"\"Usage:\n\n        f()\n    \"\"\"\n    s = \"a\"  # \"\"\" in a comment\n    if s:\n        return s\n",
+			want:  "def f():\n \"\"\"Usage:\n\n        f()\n    \"\"\"\n s = \"a\"\n if s:\n  return s\n",
+		},
+		{
+			name:  "Go indent inside a raw string",
+			lang:  "Go",
+			level: CompressIndent,
+			in:    "var usage = `\n    repogo [flags]\n        -o file\n`\n\nfunc f() {\n    s := \"`\"\n    if s != \"\" {\n        return\n    }\n}\n",
+			want:  "var usage = `\n    repogo [flags]\n        -o file\n`\n\nfunc f() {\n s := \"`\"\n if s != \"\" {\n  return\n }\n}\n",
+		},
+		{
+			// Indented code blocks and nested lists depend on their spaces.
+			name:  "Markdown",
+			lang:  "Markdown",
+			level: CompressIndent,
+			in:    "# Title  \n\n\n- item\n    - nested\n\n        code block\n<!-- note -->\n",
+			want:  "# Title  \n\n\n- item\n    - nested\n\n        code block\n<!-- note -->\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lang, ok := LookupLanguage(tt.lang)
+			if !ok {
+				t.Fatalf("unknown language %s", tt.lang)
+			}
+			got, _ := Compress(tt.in, lang, tt.level)
+			if got != tt.want {
+				t.Errorf("Compress =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompressOrigins(t *testing.T) {
+	lang, _ := LookupLanguage("Go")
+	in := "package a\n\n// one\n// two\n\n\nvar x = 1 /* a\nb */ + 2\n"
+	got, origins := Compress(in, lang, CompressComments)
+	if want := "package a\n\nvar x = 1\n + 2\n"; got != want {
+		t.Errorf("Compress =\n%s\nwant\n%s", got, want)
+	}
+	if want := []int{1, 2, 7, 8}; !reflect.DeepEqual(origins, want) {
+		t.Errorf("origins = %v, want %v", origins, want)
+	}
+}
+
+func TestCompressUnchanged(t *testing.T) {
+	diff, _ := LookupLanguage("Diff")
+	markdown, _ := LookupLanguage("Markdown")
+	for _, lang := range []*Language{nil, diff, markdown} {
+		in := "- a  \n\n\n+ b # c\n"
+		if got, origins := Compress(in, lang, CompressIndent); got != in || origins != nil {
+			t.Errorf("Compress(%v) = %q, %v; want the input unchanged", lang, got, origins)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package analyzer provides file content analysis functionality.
+package analyzer
+
+import (
+	"crypto/sha256"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/models"
+)
+
+// Near-duplicate search limits: files shorter than minNearLines are only
+// matched exactly, and at most maxNearCandidates earlier files are diffed
+// against each file.
+const (
+	minNearLines      = 8
+	maxNearCandidates = 8
+	diffContext       = 3
+)
+
+// Sum is the content hash computed for every file during the scan.
+type Sum = [sha256.Size]byte
+
+// HashContent returns the content hash used to find identical files.
+func HashContent(content []byte) Sum {
+	return sha256.Sum256(content)
+}
+
+// Duplicates finds files whose content repeats that of a file seen before.
+type Duplicates struct {
+	threshold float64
+	exact     map[Sum]string
+	files     []dedupeFile
+}
+
+// dedupeFile is an earlier file kept for near-duplicate matching. hashes
+// are the sorted hashes of its lines.
+type dedupeFile struct {
+	path   string
+	lang   string
+	lines  []string
+	hashes []uint64
+}
+
+// NewDuplicates returns an empty index. Files are near duplicates when
+// their line similarity reaches threshold; a threshold of 0 or at least 1
+// matches identical files only.
+func NewDuplicates(threshold float64) *Duplicates {
+	return &Duplicates{threshold: threshold, exact: map[Sum]string{}}
+}
+
+// Find returns how the file at path duplicates an earlier one, or nil if it
+// does not, in which case it becomes a candidate for later files. Near
+// duplicates are only matched within the same language. The similarity is
+// the share of lines the two files have in common, and Diff turns the
+// earlier file into this one.
+func (d *Duplicates) Find(path, lang string, sum Sum, content string) *models.Duplicate {
+	if of, ok := d.exact[sum]; ok {
+		return &models.Duplicate{Of: of, Similarity: 1}
+	}
+	d.exact[sum] = path
+	if d.threshold <= 0 || d.threshold >= 1 {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if len(lines) < minNearLines {
+		return nil
+	}
+	f := dedupeFile{path: path, lang: lang, lines: lines, hashes: lineHashes(lines)}
+	if dup := d.nearest(f); dup != nil {
+		return dup
+	}
+	d.files = append(d.files, f)
+	return nil
+}
+
+// nearest diffs f against the earlier files most likely to be similar and
+// returns the closest one above the threshold.
+func (d *Duplicates) nearest(f dedupeFile) *models.Duplicate {
+	type candidate struct {
+		file  *dedupeFile
+		bound float64
+	}
+	var candidates []candidate
+	for i := range d.files {
+		g := &d.files[i]
+		if g.lang != f.lang {
+			continue
+		}
+		// Lines in common bound the longest common subsequence, and so the
+		// similarity, from above.
+		if bound := similarity(len(f.lines), len(g.lines), common(f.hashes, g.hashes)); bound >= d.threshold {
+			candidates = append(candidates, candidate{g, bound})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].bound > candidates[j].bound })
+	if len(candidates) > maxNearCandidates {
+		candidates = candidates[:maxNearCandidates]
+	}
+
+	var best *models.Duplicate
+	bestEdits := 0
+	for _, c := range candidates {
+		total := len(f.lines) + len(c.file.lines)
+		maxEdits := int((1 - d.threshold) * float64(total))
+		if best != nil {
+			maxEdits = min(maxEdits, bestEdits-1)
+		}
+		edits, n, ok := diffLines(c.file.lines, f.lines, maxEdits)
+		if !ok {
+			continue
+		}
+		best = &models.Duplicate{
+			Of:         c.file.path,
+			Similarity: math.Round(similarity(len(f.lines), len(c.file.lines), (total-n)/2)*1000) / 1000,
+			Diff:       unified(edits, diffContext),
+		}
+		bestEdits = n
+	}
+	return best
+}
+
+// similarity is the share of lines two files of a and b lines have in common.
+func similarity(a, b, common int) float64 {
+	return 2 * float64(common) / float64(a+b)
+}
+
+func lineHashes(lines []string) []uint64 {
+	hashes := make([]uint64, len(lines))
+	for i, l := range lines {
+		h := fnv.New64a()
+		h.Write([]byte(l))
+		hashes[i] = h.Sum64()
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+	return hashes
+}
+
+// common counts the elements two sorted slices share, with multiplicity.
+func common(a, b []uint64) int {
+	n := 0
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			n++
+			i++
+			j++
+		}
+	}
+	return n
+}
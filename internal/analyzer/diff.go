@@ -0,0 +1,143 @@
+// Package analyzer provides file content analysis functionality.
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// edit is one line of an edit script: ' ' keeps, '-' deletes, '+' inserts.
+type edit struct {
+	op   byte
+	line string
+}
+
+// diffLines computes a shortest edit script from a to b with Myers'
+// algorithm. It gives up, returning false, once more than maxEdits lines
+// would have to be deleted or inserted.
+func diffLines(a, b []string, maxEdits int) ([]edit, int, bool) {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEdits)
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // step down: insert
+			} else {
+				x = v[offset+k-1] + 1 // step right: delete
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, offset), d, true
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+// backtrack walks the saved frontiers from the end of both inputs back to
+// the start, collecting the edits in reverse.
+func backtrack(a, b []string, trace [][]int, offset int) []edit {
+	var edits []edit
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{' ', a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, edit{'+', b[y]})
+		} else {
+			x--
+			edits = append(edits, edit{'-', a[x]})
+		}
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// unified renders an edit script as unified diff hunks with context lines
+// of unchanged text around each change.
+func unified(edits []edit, context int) string {
+	var b strings.Builder
+	for start := 0; start < len(edits); {
+		// Find the next change and extend the hunk while changes are close.
+		first := start
+		for first < len(edits) && edits[first].op == ' ' {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		end := first
+		for i := first; i < len(edits); i++ {
+			if edits[i].op != ' ' {
+				end = i + 1
+			} else if i-end >= 2*context {
+				break
+			}
+		}
+		from := max(first-context, start)
+		to := min(end+context, len(edits))
+
+		aLine, bLine := 1, 1
+		for _, e := range edits[:from] {
+			if e.op != '+' {
+				aLine++
+			}
+			if e.op != '-' {
+				bLine++
+			}
+		}
+		aCount, bCount := 0, 0
+		for _, e := range edits[from:to] {
+			if e.op != '+' {
+				aCount++
+			}
+			if e.op != '-' {
+				bCount++
+			}
+		}
+		// An empty range is numbered after the line it follows, as in GNU diff.
+		if aCount == 0 {
+			aLine--
+		}
+		if bCount == 0 {
+			bLine--
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", aLine, aCount, bLine, bCount)
+		for _, e := range edits[from:to] {
+			b.WriteByte(e.op)
+			b.WriteString(e.line)
+			b.WriteByte('\n')
+		}
+		start = to
+	}
+	return b.String()
+}
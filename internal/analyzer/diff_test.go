@@ -0,0 +1,134 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+)
+
+// lines splits s into lines, with no lines for the empty string.
+func lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		a, b string
+		d    int // edit distance
+	}{
+		{"", "", 0},
+		{"a\nb\nc", "a\nb\nc", 0},
+		{"", "a\nb", 2},
+		{"a\nb", "", 2},
+		{"a\nb\nc", "a\nc", 1},
+		{"a\nc", "a\nb\nc", 1},
+		{"a\nb\nc", "a\nx\nc", 2},
+		{"a\nb\nc\na\nb\nb\na", "c\nb\na\nb\na\nc", 5}, // Myers' paper example
+		{"x\ny\nz", "a\nb\nc", 6},
+	}
+	for _, tt := range tests {
+		a, b := lines(tt.a), lines(tt.b)
+		edits, d, ok := diffLines(a, b, 100)
+		if !ok || d != tt.d {
+			t.Errorf("diffLines(%q, %q) = distance %d, %v; want %d", tt.a, tt.b, d, ok, tt.d)
+			continue
+		}
+		// The script must turn a into b, with d deletions and insertions.
+		var from, to []string
+		changes := 0
+		for _, e := range edits {
+			if e.op != '+' {
+				from = append(from, e.line)
+			}
+			if e.op != '-' {
+				to = append(to, e.line)
+			}
+			if e.op != ' ' {
+				changes++
+			}
+		}
+		if strings.Join(from, "\n") != tt.a || strings.Join(to, "\n") != tt.b || changes != d {
+			t.Errorf("diffLines(%q, %q) = %v, which does not turn one into the other in %d edits", tt.a, tt.b, edits, d)
+		}
+	}
+
+	if _, _, ok := diffLines(lines("a\nb\nc"), lines("x\ny\nz"), 5); ok {
+		t.Error("diffLines did not give up beyond maxEdits")
+	}
+	if _, d, ok := diffLines(lines("a\nb\nc"), lines("x\ny\nz"), 6); !ok || d != 6 {
+		t.Errorf("diffLines at exactly maxEdits = %d, %v; want 6, true", d, ok)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	file := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10"
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		want    string
+	}{
+		{
+			name:    "identical",
+			a:       file,
+			b:       file,
+			context: 3,
+			want:    "",
+		},
+		{
+			name:    "change in the middle",
+			a:       file,
+			b:       strings.Replace(file, "5", "five", 1),
+			context: 2,
+			want:    "@@ -3,5 +3,5 @@\n 3\n 4\n-5\n+five\n 6\n 7\n",
+		},
+		{
+			name:    "changes close enough to share a hunk",
+			a:       file,
+			b:       strings.Replace(strings.Replace(file, "2", "two", 1), "6", "six", 1),
+			context: 2,
+			want:    "@@ -1,8 +1,8 @@\n 1\n-2\n+two\n 3\n 4\n 5\n-6\n+six\n 7\n 8\n",
+		},
+		{
+			name:    "changes in separate hunks",
+			a:       file,
+			b:       strings.Replace(strings.Replace(file, "2", "two", 1), "9", "nine", 1),
+			context: 1,
+			want:    "@@ -1,3 +1,3 @@\n 1\n-2\n+two\n 3\n@@ -8,3 +8,3 @@\n 8\n-9\n+nine\n 10\n",
+		},
+		{
+			name:    "insertion without context",
+			a:       "a\nb",
+			b:       "a\nx\nb",
+			context: 0,
+			want:    "@@ -1,0 +2,1 @@\n+x\n",
+		},
+		{
+			name:    "deletion without context",
+			a:       "a\nx\nb",
+			b:       "a\nb",
+			context: 0,
+			want:    "@@ -2,1 +1,0 @@\n-x\n",
+		},
+		{
+			name:    "into an empty file",
+			a:       "",
+			b:       "a\nb",
+			context: 3,
+			want:    "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edits, _, ok := diffLines(lines(tt.a), lines(tt.b), 100)
+			if !ok {
+				t.Fatal("diffLines gave up")
+			}
+			if got := unified(edits, tt.context); got != tt.want {
+				t.Errorf("unified =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
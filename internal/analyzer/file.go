@@ -5,21 +5,25 @@ import (
 	"bytes"
 	"io"
 	"os"
-	"path"
-	"strings"
 )
 
 // ReadFileContent reads and analyzes a file, detecting if it's binary,
 // checking for truncation, and counting lines.
 func ReadFileContent(f *os.File, maxSize int) ([]byte, bool, bool, int) {
 	defer f.Seek(0, 0) // Conservative approach: reset offset to zero after reading (though not used later)
-	// Read max bytes to detect binary and do truncation
-	buf := make([]byte, maxSize)
-	n, err := f.Read(buf)
-	if err != nil && err != io.EOF {
+	// Read one byte past the limit to tell whether the file was truncated,
+	// allocating for the file's size rather than the limit where known.
+	limit := int64(maxSize) + 1
+	size := limit
+	if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+		size = min(info.Size()+1, limit)
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, size))
+	if _, err := buf.ReadFrom(io.LimitReader(f, limit)); err != nil {
 		return nil, false, false, 0
 	}
-	data := buf[:n]
+	data := buf.Bytes()
+	n := len(data)
 
 	isBin := bytes.IndexByte(data, 0x00) >= 0 // Simple: treat files containing NUL as binary
 	trunc := false
@@ -30,44 +34,3 @@ func ReadFileContent(f *os.File, maxSize int) ([]byte, bool, bool, int) {
 	lines := bytes.Count(data, []byte{'\n'})
 	return data, isBin, trunc, lines
 }
-
-// GuessLanguage returns the language identifier for syntax highlighting
-// based on the file extension.
-func GuessLanguage(filePath string) string {
-	switch strings.ToLower(path.Ext(filePath)) {
-	case ".go":
-		return "go"
-	case ".js", ".mjs", ".cjs", ".jsx":
-		return "javascript"
-	case ".ts", ".tsx":
-		return "typescript"
-	case ".json":
-		return "json"
-	case ".md":
-		return "markdown"
-	case ".py":
-		return "python"
-	case ".rb":
-		return "ruby"
-	case ".java":
-		return "java"
-	case ".cs":
-		return "csharp"
-	case ".c", ".h":
-		return "c"
-	case ".cpp", ".cc", ".cxx", ".hpp", ".hh":
-		return "cpp"
-	case ".sh", ".bash", ".zsh":
-		return "bash"
-	case ".yml", ".yaml":
-		return "yaml"
-	case ".sql":
-		return "sql"
-	case ".html", ".htm":
-		return "html"
-	case ".css", ".scss":
-		return "css"
-	default:
-		return ""
-	}
-}
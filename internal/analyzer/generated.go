@@ -0,0 +1,204 @@
+// Package analyzer provides file content analysis functionality.
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Kinds of files reported by Classify.
+const (
+	KindGenerated = "generated"
+	KindMinified  = "minified"
+	KindVendored  = "vendored"
+)
+
+// generatedHeader matches Go's "// Code generated ... DO NOT EDIT." marker,
+// and the same sentence behind other comment leaders; generatedMarker
+// matches the "@generated" tag common in other ecosystems.
+var (
+	generatedHeader = regexp.MustCompile(`^\s*(//|#|--|;|/\*|\*|<!--)\s*Code generated .* DO NOT EDIT\.?`)
+	generatedMarker = regexp.MustCompile(`^\s*(//|#|--|;|/\*|\*|<!--).*@generated\b`)
+)
+
+// headerLines is how far into a file generated-code markers are looked for.
+const headerLines = 30
+
+// Minification thresholds: long lines with almost no whitespace.
+const (
+	minMinifiedSize       = 512
+	minMinifiedLineAvg    = 200
+	maxMinifiedWhitespace = 0.10
+)
+
+// Classify reports whether a file is generated, minified or vendored
+// (one of the Kind constants, or "" for ordinary files) and why. Explicit
+// linguist attributes take precedence over the content heuristics.
+func Classify(relPath string, content []byte, attrs *Attributes) (kind, reason string) {
+	if set, ok := attrs.Lookup(relPath, "linguist-vendored"); ok && set {
+		return KindVendored, "linguist-vendored in .gitattributes"
+	}
+	generated, explicit := attrs.Lookup(relPath, "linguist-generated")
+	if explicit {
+		if generated {
+			return KindGenerated, "linguist-generated in .gitattributes"
+		}
+		return "", ""
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(content))
+	sc.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for i := 0; i < headerLines && sc.Scan(); i++ {
+		line := sc.Text()
+		if generatedHeader.MatchString(line) {
+			return KindGenerated, "\"Code generated ... DO NOT EDIT.\" header"
+		}
+		if generatedMarker.MatchString(line) {
+			return KindGenerated, "@generated marker"
+		}
+	}
+
+	base := path.Base(relPath)
+	if strings.Contains(base, ".min.") {
+		return KindMinified, "minified file name"
+	}
+	if len(content) >= minMinifiedSize {
+		lines := bytes.Count(content, []byte{'\n'}) + 1
+		avg := len(content) / lines
+		space := 0
+		for _, c := range content {
+			if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+				space++
+			}
+		}
+		ratio := float64(space) / float64(len(content))
+		if avg >= minMinifiedLineAvg && ratio < maxMinifiedWhitespace {
+			return KindMinified, fmt.Sprintf("average line length %d, %.0f%% whitespace", avg, ratio*100)
+		}
+	}
+	return "", ""
+}
+
+// Attributes holds the linguist-* attributes of .gitattributes files.
+// A nil *Attributes has no rules.
+type Attributes struct {
+	rules []attrRule
+}
+
+// attrRule is one attribute setting from a .gitattributes line. prefix is
+// the scan root relative to the directory holding that .gitattributes.
+type attrRule struct {
+	prefix  string
+	pattern string
+	attr    string
+	set     bool
+}
+
+// LoadAttributes reads the .gitattributes files of root and of its parent
+// directories up to the enclosing git working tree. Files deeper inside
+// root are not consulted.
+func LoadAttributes(root string) *Attributes {
+	var dirs []string
+	for dir := root; ; {
+		dirs = append(dirs, dir)
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			dirs = dirs[:1] // not in a repository: root only
+			break
+		}
+		dir = parent
+	}
+	a := &Attributes{}
+	// Outermost first, so that rules closer to the files override them.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		data, err := os.ReadFile(filepath.Join(dirs[i], ".gitattributes"))
+		if err != nil {
+			continue
+		}
+		prefix, _ := filepath.Rel(dirs[i], root)
+		a.parse(filepath.ToSlash(prefix), string(data))
+	}
+	return a
+}
+
+func (a *Attributes) parse(prefix, data string) {
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		for _, f := range fields[1:] {
+			// "attr", "attr=true", "-attr", "attr=false" or "!attr".
+			name, value, hasValue := strings.Cut(f, "=")
+			set := true
+			switch {
+			case strings.HasPrefix(name, "-") || strings.HasPrefix(name, "!"):
+				name, set = name[1:], false
+			case hasValue:
+				set = value != "false"
+			}
+			if strings.HasPrefix(name, "linguist-") {
+				a.rules = append(a.rules, attrRule{prefix: prefix, pattern: fields[0], attr: name, set: set})
+			}
+		}
+	}
+}
+
+// Lookup returns the value of attr for relPath (slash-separated, relative
+// to the scan root) and whether any rule sets it; the last match wins.
+func (a *Attributes) Lookup(relPath, attr string) (set, ok bool) {
+	if a == nil {
+		return false, false
+	}
+	for _, r := range a.rules {
+		if r.attr != attr {
+			continue
+		}
+		if matchAttrPattern(r.pattern, path.Join(r.prefix, relPath)) {
+			set, ok = r.set, true
+		}
+	}
+	return set, ok
+}
+
+// matchAttrPattern matches a .gitattributes pattern against a path relative
+// to the directory of the .gitattributes file. Patterns without a slash
+// match the base name at any depth; others match from the directory, with
+// "**" spanning any number of path segments.
+func matchAttrPattern(pattern, rel string) bool {
+	if !strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(strings.TrimPrefix(pattern, "/"), "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
@@ -0,0 +1,35 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	attrs := &Attributes{}
+	attrs.parse("", "vendor/** linguist-vendored\n*.pb.go linguist-generated=false\napi/*.gen linguist-generated\n")
+	minified := strings.Repeat("var a=function(b){return b+1};", 40)
+	tests := []struct {
+		path    string
+		content string
+		kind    string
+	}{
+		{"main.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage main\n", KindGenerated},
+		{"schema.py", "# Code generated by tool DO NOT EDIT\n", KindGenerated},
+		{"lib.js", "/**\n * @generated\n */\nexport {}\n", KindGenerated},
+		{"late.go", strings.Repeat("\n", headerLines) + "// Code generated by x. DO NOT EDIT.\n", ""},
+		{"doc.go", "// Code generated code is not marked here.\npackage doc\n", ""},
+		{"app.min.css", "a{}", KindMinified},
+		{"bundle.js", minified, KindMinified},
+		{"short.js", minified[:300], ""},
+		{"vendor/lib/x.go", "package x\n", KindVendored},
+		{"api/types.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\n", ""},
+		{"api/client.gen", "anything\n", KindGenerated},
+		{"main_test.go", "package main\n", ""},
+	}
+	for _, tt := range tests {
+		if kind, reason := Classify(tt.path, []byte(tt.content), attrs); kind != tt.kind || (kind != "") != (reason != "") {
+			t.Errorf("Classify(%q) = %q, %q; want %q", tt.path, kind, reason, tt.kind)
+		}
+	}
+}
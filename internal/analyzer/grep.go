@@ -0,0 +1,83 @@
+// Package analyzer provides file content analysis functionality.
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/models"
+)
+
+// Matcher selects files by content using one or more regular expressions.
+type Matcher struct {
+	patterns []*regexp.Regexp
+	all      bool
+}
+
+// NewMatcher compiles patterns. With all set a file must match every
+// pattern, otherwise any one of them suffices.
+func NewMatcher(patterns []string, all bool) (*Matcher, error) {
+	m := &Matcher{all: all}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+		m.patterns = append(m.patterns, re)
+	}
+	return m, nil
+}
+
+// Match reports whether content satisfies the matcher.
+func (m *Matcher) Match(content string) bool {
+	for _, re := range m.patterns {
+		if re.MatchString(content) != m.all {
+			return !m.all
+		}
+	}
+	return m.all
+}
+
+// matchLine reports whether any pattern matches line.
+func (m *Matcher) matchLine(line string) bool {
+	for _, re := range m.patterns {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// Snippets returns the regions of content around lines matching any
+// pattern, each extended by context lines on both sides (none if context
+// is negative). Overlapping and adjacent regions are merged.
+func (m *Matcher) Snippets(content string, context int) []models.Snippet {
+	context = max(context, 0)
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	var snippets []models.Snippet
+	start, end := -1, -1 // current region, 0-based inclusive
+	flush := func() {
+		if start >= 0 {
+			snippets = append(snippets, models.Snippet{
+				StartLine: start + 1,
+				EndLine:   end + 1,
+				Content:   strings.Join(lines[start:end+1], "\n"),
+			})
+		}
+	}
+	for i, line := range lines {
+		if !m.matchLine(line) {
+			continue
+		}
+		lo, hi := max(i-context, 0), min(i+context, len(lines)-1)
+		if start >= 0 && lo <= end+1 {
+			end = max(end, hi)
+			continue
+		}
+		flush()
+		start, end = lo, hi
+	}
+	flush()
+	return snippets
+}
@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/models"
+)
+
+func TestMatcherMatch(t *testing.T) {
+	content := "func main() {\n\t// TODO: flags\n}\n"
+	tests := []struct {
+		patterns []string
+		all      bool
+		want     bool
+	}{
+		{[]string{"TODO"}, false, true},
+		{[]string{"FIXME"}, false, false},
+		{[]string{"FIXME", "TODO"}, false, true},
+		{[]string{"FIXME", "TODO"}, true, false},
+		{[]string{`func \w+\(`, "TODO"}, true, true},
+	}
+	for _, tt := range tests {
+		m, err := NewMatcher(tt.patterns, tt.all)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Match(content); got != tt.want {
+			t.Errorf("Match(%q, all=%v) = %v, want %v", tt.patterns, tt.all, got, tt.want)
+		}
+	}
+	if _, err := NewMatcher([]string{"("}, false); err == nil {
+		t.Error("NewMatcher accepted an invalid pattern")
+	}
+}
+
+func TestSnippets(t *testing.T) {
+	// Lines 1-10; "x" marks the matches on lines 2, 4 and 9.
+	content := "a\nx\nb\nx\nc\nd\ne\nf\nx\ng\n"
+	snippet := func(start, end int, text string) models.Snippet {
+		return models.Snippet{StartLine: start, EndLine: end, Content: text}
+	}
+	tests := []struct {
+		name    string
+		context int
+		want    []models.Snippet
+	}{
+		{"no context", 0, []models.Snippet{snippet(2, 2, "x"), snippet(4, 4, "x"), snippet(9, 9, "x")}},
+		{"nearby regions merge", 1, []models.Snippet{snippet(1, 5, "a\nx\nb\nx\nc"), snippet(8, 10, "f\nx\ng")}},
+		{"overlapping regions merge", 2, []models.Snippet{snippet(1, 10, "a\nx\nb\nx\nc\nd\ne\nf\nx\ng")}},
+		{"context past both ends", 20, []models.Snippet{snippet(1, 10, "a\nx\nb\nx\nc\nd\ne\nf\nx\ng")}},
+		{"negative context", -1, []models.Snippet{snippet(2, 2, "x"), snippet(4, 4, "x"), snippet(9, 9, "x")}},
+	}
+	m, err := NewMatcher([]string{"^x$"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Snippets(content, tt.context); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Snippets(context=%d) = %+v, want %+v", tt.context, got, tt.want)
+			}
+		})
+	}
+	if got := m.Snippets("nothing\n", 3); got != nil {
+		t.Errorf("Snippets without matches = %+v, want none", got)
+	}
+}
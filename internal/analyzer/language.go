@@ -0,0 +1,359 @@
+// Package analyzer provides file content analysis functionality.
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Language describes how a programming or markup language is recognized.
+// Definitions are matched (in order of precedence) by modeline, exact
+// filename, shebang interpreter and finally file extension.
+type Language struct {
+	Name         string   `json:"name"`
+	Fence        string   `json:"fence,omitempty"`
+	Aliases      []string `json:"aliases,omitempty"`
+	Extensions   []string `json:"extensions,omitempty"`
+	Filenames    []string `json:"filenames,omitempty"`
+	Interpreters []string `json:"interpreters,omitempty"`
+
+	// LineComments and BlockComments describe the comment syntax used when
+	// counting code and comment lines. Quotes are the string delimiters
+	// whose contents comment stripping leaves alone (double quotes when
+	// empty); single and double quotes end at the line break, triple quotes
+	// and backquotes may span lines.
+	LineComments  []string    `json:"line_comments,omitempty"`
+	BlockComments [][2]string `json:"block_comments,omitempty"`
+	Quotes        []string    `json:"quotes,omitempty"`
+}
+
+// Common comment syntaxes shared by many languages.
+var (
+	slashLine   = []string{"//"}
+	hashLine    = []string{"#"}
+	cBlock      = [][2]string{{"/*", "*/"}}
+	markupBlock = [][2]string{{"<!--", "-->"}}
+
+	doubleQuote  = []string{`"`}
+	singleQuote  = []string{`'`}
+	bothQuotes   = []string{`"`, `'`}
+	scriptQuotes = []string{`"`, `'`, "`"}
+	tripleQuotes = []string{`"""`, `'''`, `"`, `'`}
+)
+
+// builtinLanguages is the default language table. Extensions include the
+// leading dot; filenames and extensions are matched case-insensitively.
+var builtinLanguages = []Language{
+	{Name: "Go", Fence: "go", Aliases: []string{"golang"}, Extensions: []string{".go"}, LineComments: slashLine, BlockComments: cBlock, Quotes: []string{`"`, `'`, "`"}},
+	{Name: "Go Module", Fence: "go", Filenames: []string{"go.mod", "go.work"}, LineComments: slashLine, Quotes: []string{`"`, "`"}},
+	{Name: "Go Checksums", Fence: "text", Filenames: []string{"go.sum", "go.work.sum"}},
+	{Name: "JavaScript", Fence: "javascript", Aliases: []string{"js", "node"}, Extensions: []string{".js", ".mjs", ".cjs", ".jsx"}, Interpreters: []string{"node", "nodejs", "deno", "bun"}, LineComments: slashLine, BlockComments: cBlock, Quotes: scriptQuotes},
+	{Name: "TypeScript", Fence: "typescript", Aliases: []string{"ts"}, Extensions: []string{".ts", ".tsx", ".mts", ".cts"}, Interpreters: []string{"ts-node", "tsx"}, LineComments: slashLine, BlockComments: cBlock, Quotes: scriptQuotes},
+	{Name: "JSON", Fence: "json", Extensions: []string{".json", ".jsonc", ".json5", ".jsonl", ".ndjson", ".geojson", ".webmanifest"}, Filenames: []string{".babelrc", ".eslintrc", ".prettierrc", "composer.lock", "flake.lock"}},
+	{Name: "Markdown", Fence: "markdown", Aliases: []string{"md"}, Extensions: []string{".md", ".markdown", ".mdx"}, BlockComments: markupBlock, Quotes: []string{"`"}},
+	{Name: "reStructuredText", Fence: "rst", Aliases: []string{"rst"}, Extensions: []string{".rst"}},
+	{Name: "Python", Fence: "python", Aliases: []string{"py"}, Extensions: []string{".py", ".pyi", ".pyw", ".pyx", ".gyp"}, Filenames: []string{"SConstruct", "SConscript"}, Interpreters: []string{"python", "pypy"}, LineComments: hashLine, Quotes: tripleQuotes},
+	{Name: "Starlark", Fence: "python", Aliases: []string{"bzl", "bazel"}, Extensions: []string{".bzl", ".star"}, Filenames: []string{"BUILD", "BUILD.bazel", "WORKSPACE", "WORKSPACE.bazel", "MODULE.bazel", "BUCK", "Tiltfile"}, LineComments: hashLine, Quotes: tripleQuotes},
+	{Name: "Ruby", Fence: "ruby", Aliases: []string{"rb"}, Extensions: []string{".rb", ".rake", ".gemspec", ".ru", ".erb"}, Filenames: []string{"Gemfile", "Rakefile", "Guardfile", "Podfile", "Vagrantfile", "Brewfile", ".irbrc", ".pryrc"}, Interpreters: []string{"ruby", "jruby", "rake"}, LineComments: hashLine, BlockComments: [][2]string{{"=begin", "=end"}}, Quotes: scriptQuotes},
+	{Name: "Java", Fence: "java", Extensions: []string{".java"}, LineComments: slashLine, BlockComments: cBlock, Quotes: bothQuotes},
+	{Name: "Kotlin", Fence: "kotlin", Aliases: []string{"kt"}, Extensions: []string{".kt", ".kts"}, LineComments: slashLine, BlockComments: cBlock, Quotes: []string{`"""`, `"`, `'`}},
+	{Name: "Scala", Fence: "scala", Extensions: []string{".scala", ".sc", ".sbt"}, Interpreters: []string{"scala"}, LineComments: slashLine, BlockComments: cBlock, Quotes: []string{`"""`, `"`}},
+	{Name: "Groovy", Fence: "groovy", Extensions: []string{".groovy", ".gradle", ".gvy"}, Filenames: []string{"Jenkinsfile"}, Interpreters: []string{"groovy"}, LineComments: slashLine, BlockComments: cBlock, Quotes: tripleQuotes},
+	{Name: "C#", Fence: "csharp", Aliases: []string{"csharp", "cs"}, Extensions: []string{".cs", ".csx"}, LineComments: slashLine, BlockComments: cBlock, Quotes: bothQuotes},
+	{Name: "F#", Fence: "fsharp", Aliases: []string{"fsharp"}, Extensions: []string{".fs", ".fsi", ".fsx"}, LineComments: slashLine, BlockComments: [][2]string{{"(*", "*)"}}, Quotes: []string{`"""`, `"`}},
+	{Name: "Visual Basic .NET", Fence: "vbnet", Aliases: []string{"vb", "vbnet"}, Extensions: []string{".vb"}, LineComments: []string{"'"}, Quotes: doubleQuote},
+	{Name: "C", Fence: "c", Extensions: []string{".c", ".h"}, LineComments: slashLine, BlockComments: cBlock, Quotes: bothQuotes},
+	{Name: "C++", Fence: "cpp", Aliases: []string{"cpp", "c++"}, Extensions: []string{".cpp", ".cc", ".cxx", ".c++", ".hpp", ".hh", ".hxx", ".h++", ".ipp", ".inl"}, LineComments: slashLine, BlockComments: cBlock, Quotes: bothQuotes},
+	{Name: "Objective-C", Fence: "objectivec", Aliases: []string{"objc"}, Extensions: []string{".m", ".mm"}, LineComments: slashLine, BlockComments: cBlock, Quotes: bothQuotes},
+	{Name: "Swift", Fence: "swift", Extensions: []string{".swift"}, LineComments: slashLine, BlockComments: cBlock, Quotes: []string{`"""`, `"`}},
+	{Name: "Rust", Fence: "rust", Aliases: []string{"rs"}, Extensions: []string{".rs"}, LineComments: slashLine, BlockComments: cBlock, Quotes: doubleQuote},
+	{Name: "Zig", Fence: "zig", Extensions: []string{".zig"}, LineComments: slashLine, Quotes: bothQuotes},
+	{Name: "Dart", Fence: "dart", Extensions: []string{".dart"}, LineComments: slashLine, BlockComments: cBlock, Quotes: tripleQuotes},
+	{Name: "PHP", Fence: "php", Extensions: []string{".php", ".phtml"}, Interpreters: []string{"php"}, LineComments: []string{"//", "#"}, BlockComments: cBlock, Quotes: scriptQuotes},
+	{Name: "Perl", Fence: "perl", Aliases: []string{"pl"}, Extensions: []string{".pl", ".pm", ".t"}, Interpreters: []string{"perl"}, LineComments: hashLine, Quotes: scriptQuotes},
+	{Name: "Lua", Fence: "lua", Extensions: []string{".lua"}, Interpreters: []string{"lua", "luajit"}, LineComments: []string{"--"}, BlockComments: [][2]string{{"--[[", "]]"}}, Quotes: bothQuotes},
+	{Name: "R", Fence: "r", Extensions: []string{".r"}, Filenames: []string{".Rprofile"}, Interpreters: []string{"Rscript"}, LineComments: hashLine, Quotes: scriptQuotes},
+	{Name: "Julia", Fence: "julia", Extensions: []string{".jl"}, Interpreters: []string{"julia"}, LineComments: hashLine, BlockComments: [][2]string{{"#=", "=#"}}, Quotes: []string{`"""`, `"`}},
+	{Name: "Elixir", Fence: "elixir", Aliases: []string{"ex"}, Extensions: []string{".ex", ".exs"}, Filenames: []string{"mix.lock"}, Interpreters: []string{"elixir"}, LineComments: hashLine, Quotes: tripleQuotes},
+	{Name: "Erlang", Fence: "erlang", Extensions: []string{".erl", ".hrl"}, Filenames: []string{"rebar.config"}, Interpreters: []string{"escript"}, LineComments: []string{"%"}, Quotes: bothQuotes},
+	{Name: "Haskell", Fence: "haskell", Aliases: []string{"hs"}, Extensions: []string{".hs", ".lhs"}, Interpreters: []string{"runhaskell"}, LineComments: []string{"--"}, BlockComments: [][2]string{{"{-", "-}"}}, Quotes: doubleQuote},
+	{Name: "OCaml", Fence: "ocaml", Extensions: []string{".ml", ".mli"}, Interpreters: []string{"ocaml"}, BlockComments: [][2]string{{"(*", "*)"}}, Quotes: doubleQuote},
+	{Name: "Clojure", Fence: "clojure", Aliases: []string{"clj"}, Extensions: []string{".clj", ".cljs", ".cljc", ".edn"}, LineComments: []string{";"}, Quotes: doubleQuote},
+	{Name: "Emacs Lisp", Fence: "elisp", Aliases: []string{"elisp", "emacs-lisp"}, Extensions: []string{".el"}, Filenames: []string{".emacs"}, LineComments: []string{";"}, Quotes: doubleQuote},
+	{Name: "Shell", Fence: "bash", Aliases: []string{"sh", "bash", "zsh", "shell-script"}, Extensions: []string{".sh", ".bash", ".zsh", ".ksh", ".bats"}, Filenames: []string{".bashrc", ".bash_profile", ".bash_aliases", ".bash_logout", ".profile", ".zshrc", ".zprofile", ".zshenv", ".envrc", "PKGBUILD"}, Interpreters: []string{"sh", "bash", "zsh", "ksh", "dash", "ash"}, LineComments: hashLine, Quotes: scriptQuotes},
+	{Name: "Fish", Fence: "fish", Extensions: []string{".fish"}, Interpreters: []string{"fish"}, LineComments: hashLine, Quotes: bothQuotes},
+	{Name: "PowerShell", Fence: "powershell", Aliases: []string{"ps1", "pwsh"}, Extensions: []string{".ps1", ".psm1", ".psd1"}, Interpreters: []string{"pwsh", "powershell"}, LineComments: hashLine, BlockComments: [][2]string{{"<#", "#>"}}, Quotes: bothQuotes},
+	{Name: "Batchfile", Fence: "batch", Aliases: []string{"bat", "cmd"}, Extensions: []string{".bat", ".cmd"}, LineComments: []string{"REM ", "rem ", "::"}, Quotes: doubleQuote},
+	{Name: "YAML", Fence: "yaml", Aliases: []string{"yml"}, Extensions: []string{".yml", ".yaml"}, Filenames: []string{".clang-format", ".clang-tidy", ".gemrc"}, LineComments: hashLine, Quotes: bothQuotes},
+	{Name: "TOML", Fence: "toml", Extensions: []string{".toml"}, Filenames: []string{"Cargo.lock", "Pipfile", "poetry.lock"}, LineComments: hashLine, Quotes: tripleQuotes},
+	{Name: "INI", Fence: "ini", Aliases: []string{"dosini", "cfg"}, Extensions: []string{".ini", ".cfg", ".conf", ".properties"}, Filenames: []string{".editorconfig", ".gitconfig", ".npmrc", ".pylintrc", "setup.cfg", "tox.ini"}, LineComments: []string{";", "#"}, Quotes: doubleQuote},
+	{Name: "Dotenv", Fence: "dotenv", Extensions: []string{".env"}, Filenames: []string{".env", ".env.example", ".env.local", ".env.sample"}, LineComments: hashLine, Quotes: bothQuotes},
+	{Name: "XML", Fence: "xml", Extensions: []string{".xml", ".xsd", ".xsl", ".xslt", ".plist", ".csproj", ".fsproj", ".vbproj", ".props", ".targets", ".svg"}, Filenames: []string{"pom.xml"}, BlockComments: markupBlock, Quotes: bothQuotes},
+	{Name: "HTML", Fence: "html", Aliases: []string{"xhtml"}, Extensions: []string{".html", ".htm", ".xhtml"}, BlockComments: markupBlock, Quotes: bothQuotes},
+	{Name: "Vue", Fence: "vue", Extensions: []string{".vue"}, BlockComments: markupBlock, Quotes: scriptQuotes},
+	{Name: "Svelte", Fence: "svelte", Extensions: []string{".svelte"}, BlockComments: markupBlock, Quotes: scriptQuotes},
+	{Name: "CSS", Fence: "css", Extensions: []string{".css"}, BlockComments: cBlock, Quotes: bothQuotes},
+	{Name: "SCSS", Fence: "scss", Extensions: []string{".scss"}, LineComments: slashLine, BlockComments: cBlock, Quotes: bothQuotes},
+	{Name: "Sass", Fence: "sass", Extensions: []string{".sass"}, LineComments: slashLine, BlockComments: cBlock, Quotes: bothQuotes},
+	{Name: "Less", Fence: "less", Extensions: []string{".less"}, LineComments: slashLine, BlockComments: cBlock, Quotes: bothQuotes},
+	{Name: "SQL", Fence: "sql", Extensions: []string{".sql", ".ddl", ".dml"}, LineComments: []string{"--"}, BlockComments: cBlock, Quotes: bothQuotes},
+	{Name: "GraphQL", Fence: "graphql", Aliases: []string{"gql"}, Extensions: []string{".graphql", ".gql", ".graphqls"}, LineComments: hashLine, Quotes: []string{`"""`, `"`}},
+	{Name: "Protocol Buffer", Fence: "protobuf", Aliases: []string{"proto", "protobuf"}, Extensions: []string{".proto"}, LineComments: slashLine, BlockComments: cBlock, Quotes: bothQuotes},
+	{Name: "Thrift", Fence: "thrift", Extensions: []string{".thrift"}, LineComments: []string{"//", "#"}, BlockComments: cBlock, Quotes: bothQuotes},
+	{Name: "HCL", Fence: "hcl", Aliases: []string{"terraform", "tf"}, Extensions: []string{".tf", ".tfvars", ".hcl", ".nomad"}, Filenames: []string{".terraform.lock.hcl"}, LineComments: []string{"#", "//"}, BlockComments: cBlock, Quotes: doubleQuote},
+	{Name: "Nix", Fence: "nix", Extensions: []string{".nix"}, LineComments: hashLine, BlockComments: cBlock, Quotes: doubleQuote},
+	{Name: "Dockerfile", Fence: "dockerfile", Aliases: []string{"docker", "containerfile"}, Extensions: []string{".dockerfile"}, Filenames: []string{"Dockerfile", "Containerfile"}, LineComments: hashLine, Quotes: bothQuotes},
+	{Name: "Makefile", Fence: "makefile", Aliases: []string{"make", "mf"}, Extensions: []string{".mk", ".mak", ".make"}, Filenames: []string{"Makefile", "GNUmakefile", "makefile", "BSDmakefile"}, Interpreters: []string{"make"}, LineComments: hashLine, Quotes: bothQuotes},
+	{Name: "CMake", Fence: "cmake", Extensions: []string{".cmake"}, Filenames: []string{"CMakeLists.txt"}, LineComments: hashLine, Quotes: doubleQuote},
+	{Name: "Meson", Fence: "meson", Filenames: []string{"meson.build", "meson_options.txt"}, LineComments: hashLine, Quotes: []string{`'''`, `'`}},
+	{Name: "Just", Fence: "just", Extensions: []string{".just"}, Filenames: []string{"justfile", "Justfile", ".justfile"}, LineComments: hashLine, Quotes: []string{`"""`, `'''`, `"`, `'`, "`"}},
+	{Name: "Git Config", Fence: "gitconfig", Filenames: []string{".gitmodules"}, LineComments: []string{"#", ";"}, Quotes: doubleQuote},
+	{Name: "Ignore List", Fence: "gitignore", Aliases: []string{"gitignore"}, Extensions: []string{".gitignore", ".dockerignore"}, Filenames: []string{".gitignore", ".dockerignore", ".npmignore", ".eslintignore", ".prettierignore", ".helmignore"}, LineComments: hashLine},
+	{Name: "Git Attributes", Fence: "gitattributes", Filenames: []string{".gitattributes"}, LineComments: hashLine, Quotes: doubleQuote},
+	{Name: "Diff", Fence: "diff", Aliases: []string{"patch"}, Extensions: []string{".diff", ".patch"}},
+	{Name: "TeX", Fence: "latex", Aliases: []string{"latex", "tex"}, Extensions: []string{".tex", ".sty", ".cls", ".bib"}, LineComments: []string{"%"}},
+	{Name: "Vim Script", Fence: "vim", Aliases: []string{"vim", "viml"}, Extensions: []string{".vim"}, Filenames: []string{".vimrc", ".gvimrc", "_vimrc"}, LineComments: []string{"\""}, Quotes: bothQuotes},
+	{Name: "AsciiDoc", Fence: "asciidoc", Aliases: []string{"adoc"}, Extensions: []string{".adoc", ".asciidoc"}},
+	{Name: "Text", Fence: "text", Aliases: []string{"txt", "plaintext"}, Extensions: []string{".txt"}, Filenames: []string{"LICENSE", "LICENCE", "COPYING", "NOTICE", "AUTHORS", "CODEOWNERS", "requirements.txt"}},
+	{Name: "CSV", Fence: "csv", Extensions: []string{".csv", ".tsv"}},
+	{Name: "Assembly", Fence: "asm", Aliases: []string{"asm", "nasm"}, Extensions: []string{".asm", ".s", ".nasm"}, LineComments: []string{";", "#"}, Quotes: bothQuotes},
+	{Name: "Solidity", Fence: "solidity", Aliases: []string{"sol"}, Extensions: []string{".sol"}, LineComments: slashLine, BlockComments: cBlock, Quotes: bothQuotes},
+	{Name: "Verilog", Fence: "verilog", Extensions: []string{".v", ".sv", ".svh"}, LineComments: slashLine, BlockComments: cBlock, Quotes: doubleQuote},
+	{Name: "VHDL", Fence: "vhdl", Extensions: []string{".vhd", ".vhdl"}, LineComments: []string{"--"}, Quotes: doubleQuote},
+	{Name: "Fortran", Fence: "fortran", Extensions: []string{".f", ".f90", ".f95", ".f03", ".for"}, LineComments: []string{"!"}, Quotes: bothQuotes},
+	{Name: "Pascal", Fence: "pascal", Extensions: []string{".pas", ".pp"}, LineComments: slashLine, BlockComments: [][2]string{{"{", "}"}, {"(*", "*)"}}, Quotes: singleQuote},
+	{Name: "AWK", Fence: "awk", Extensions: []string{".awk"}, Interpreters: []string{"awk", "gawk", "mawk", "nawk"}, LineComments: hashLine, Quotes: doubleQuote},
+	{Name: "Tcl", Fence: "tcl", Extensions: []string{".tcl"}, Interpreters: []string{"tclsh", "wish"}, LineComments: hashLine, Quotes: doubleQuote},
+}
+
+// languageTable indexes language definitions for fast lookup.
+type languageTable struct {
+	mu          sync.RWMutex
+	byName      map[string]*Language
+	byAlias     map[string]*Language
+	byExtension map[string]*Language
+	byFilename  map[string]*Language
+	byInterp    map[string]*Language
+}
+
+var languages = newLanguageTable(builtinLanguages)
+
+func newLanguageTable(defs []Language) *languageTable {
+	t := &languageTable{
+		byName:      map[string]*Language{},
+		byAlias:     map[string]*Language{},
+		byExtension: map[string]*Language{},
+		byFilename:  map[string]*Language{},
+		byInterp:    map[string]*Language{},
+	}
+	for _, l := range defs {
+		t.register(l)
+	}
+	return t
+}
+
+// register adds l to the table. If a language with the same name already
+// exists, the new extensions, filenames, interpreters and aliases are merged
+// into it; later registrations win when two languages claim the same key.
+func (t *languageTable) register(l Language) {
+	key := strings.ToLower(l.Name)
+	lang, ok := t.byName[key]
+	if !ok {
+		lang = &Language{Name: l.Name}
+		t.byName[key] = lang
+	}
+	if l.Fence != "" {
+		lang.Fence = l.Fence
+		// Several languages share a fence (e.g. "go" for go.mod); the
+		// first one registered keeps it as an alias.
+		if _, taken := t.byAlias[strings.ToLower(l.Fence)]; !taken {
+			t.byAlias[strings.ToLower(l.Fence)] = lang
+		}
+	}
+	for _, a := range l.Aliases {
+		lang.Aliases = append(lang.Aliases, a)
+		t.byAlias[strings.ToLower(a)] = lang
+	}
+	for _, e := range l.Extensions {
+		if !strings.HasPrefix(e, ".") {
+			e = "." + e
+		}
+		lang.Extensions = append(lang.Extensions, e)
+		t.byExtension[strings.ToLower(e)] = lang
+	}
+	for _, f := range l.Filenames {
+		lang.Filenames = append(lang.Filenames, f)
+		t.byFilename[strings.ToLower(f)] = lang
+	}
+	for _, i := range l.Interpreters {
+		lang.Interpreters = append(lang.Interpreters, i)
+		t.byInterp[i] = lang
+	}
+	lang.LineComments = append(lang.LineComments, l.LineComments...)
+	lang.BlockComments = append(lang.BlockComments, l.BlockComments...)
+	lang.Quotes = append(lang.Quotes, l.Quotes...)
+}
+
+// RegisterLanguage adds or extends a language definition in the global table.
+func RegisterLanguage(l Language) error {
+	if strings.TrimSpace(l.Name) == "" {
+		return fmt.Errorf("language definition is missing a name")
+	}
+	languages.mu.Lock()
+	defer languages.mu.Unlock()
+	languages.register(l)
+	return nil
+}
+
+// LoadLanguages reads a JSON array of Language definitions from the given
+// file and registers each of them.
+func LoadLanguages(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var defs []Language
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return fmt.Errorf("parse %s: %w", file, err)
+	}
+	for _, l := range defs {
+		if err := RegisterLanguage(l); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// LookupLanguage finds a language by name, fence or alias (case-insensitive).
+func LookupLanguage(name string) (*Language, bool) {
+	languages.mu.RLock()
+	defer languages.mu.RUnlock()
+	return languages.lookup(name)
+}
+
+func (t *languageTable) lookup(name string) (*Language, bool) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if l, ok := t.byName[key]; ok {
+		return l, true
+	}
+	l, ok := t.byAlias[key]
+	return l, ok
+}
+
+// DetectLanguage identifies the language of a file from its path and the
+// beginning of its content. Content may be nil, in which case only the path
+// is considered. Returns nil if the language is unknown.
+func DetectLanguage(filePath string, content []byte) *Language {
+	languages.mu.RLock()
+	defer languages.mu.RUnlock()
+
+	if name := modelineLanguage(content); name != "" {
+		if l, ok := languages.lookup(name); ok {
+			return l
+		}
+	}
+	base := path.Base(filePath)
+	if l, ok := languages.byFilename[strings.ToLower(base)]; ok {
+		return l
+	}
+	if interp := shebangInterpreter(content); interp != "" {
+		if l, ok := languages.byInterp[interp]; ok {
+			return l
+		}
+	}
+	// Try the longest multi-part extension first, e.g. ".d.ts" before ".ts".
+	// A leading dot marks a dotfile rather than an extension.
+	lower := strings.ToLower(base)
+	for i := 1; i < len(lower); i++ {
+		if lower[i] != '.' {
+			continue
+		}
+		if l, ok := languages.byExtension[lower[i:]]; ok {
+			return l
+		}
+	}
+	return nil
+}
+
+// GuessLanguage returns the language identifier for syntax highlighting
+// based on the file path alone.
+func GuessLanguage(filePath string) string {
+	if l := DetectLanguage(filePath, nil); l != nil {
+		return l.Fence
+	}
+	return ""
+}
+
+var (
+	vimModeline   = regexp.MustCompile(`(?:^|\s)(?:vi|vim|ex)(?:[<=>]?\d+)?:.*?[\s:](?:ft|filetype|syntax)=([\w+#-]+)`)
+	emacsModeline = regexp.MustCompile(`-\*-\s*(?:.*?mode:\s*)?([\w+#-]+)\s*(?:;.*?)?-\*-`)
+)
+
+// modelineLanguage looks for a vim or emacs modeline in the first and last
+// five lines of content and returns the language it names.
+func modelineLanguage(content []byte) string {
+	if len(content) == 0 {
+		return ""
+	}
+	var lines [][]byte
+	sc := bufio.NewScanner(bytes.NewReader(content))
+	sc.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for sc.Scan() {
+		lines = append(lines, sc.Bytes())
+	}
+	candidates := lines
+	if len(lines) > 10 {
+		candidates = append(append([][]byte{}, lines[:5]...), lines[len(lines)-5:]...)
+	}
+	for _, line := range candidates {
+		if m := emacsModeline.FindSubmatch(line); m != nil {
+			return string(m[1])
+		}
+		if m := vimModeline.FindSubmatch(line); m != nil {
+			return string(m[1])
+		}
+	}
+	return ""
+}
+
+var interpreterVersion = regexp.MustCompile(`[\d.]+$`)
+
+// shebangInterpreter extracts the interpreter name from a "#!" line,
+// looking through /usr/bin/env and stripping version suffixes
+// (e.g. "python3.11" becomes "python").
+func shebangInterpreter(content []byte) string {
+	if !bytes.HasPrefix(content, []byte("#!")) {
+		return ""
+	}
+	line := content[2:]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+	interp := path.Base(fields[0])
+	if interp == "env" {
+		interp = ""
+		for _, f := range fields[1:] {
+			// Skip env options such as -S and variable assignments.
+			if strings.HasPrefix(f, "-") || strings.Contains(f, "=") {
+				continue
+			}
+			interp = path.Base(f)
+			break
+		}
+	}
+	if l := interpreterVersion.ReplaceAllString(interp, ""); l != "" {
+		interp = l
+	}
+	return interp
+}
@@ -0,0 +1,131 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+		want    string // language name, "" for unknown
+	}{
+		{"main.go", "", "Go"},
+		{"src/App.TSX", "", "TypeScript"},
+		{"types.d.ts", "", "TypeScript"},
+		{"archive.tar.gz", "", ""},
+		{".bashrc", "", "Shell"},
+		{"Dockerfile", "", "Dockerfile"},
+		{"go.mod", "", "Go Module"},
+		{"notes", "", ""},
+		{".gitignore", "", "Ignore List"},
+
+		// Shebangs, through env and without version numbers.
+		{"run", "#!/bin/sh\necho hi\n", "Shell"},
+		{"tool", "#!/usr/bin/env python3.11\n", "Python"},
+		{"tool", "#!/usr/bin/env -S deno run --allow-net\n", "JavaScript"},
+		{"tool", "#!/usr/bin/env PYTHONPATH=. python3 -u\n", "Python"},
+		{"tool", "#!/usr/local/bin/ruby2.7 -w\n", "Ruby"},
+		{"tool", "#!/usr/bin/unknown-interp\n", ""},
+
+		// Filenames win over shebangs, shebangs over extensions.
+		{"Makefile", "#!/usr/bin/env python\n", "Makefile"},
+		{"script.txt", "#!/usr/bin/env bash\n", "Shell"},
+
+		// Modelines win over everything.
+		{"Makefile", "# vim: set ft=python :\n", "Python"},
+		{"config.txt", "#!/bin/sh\n# -*- mode: ruby -*-\n", "Ruby"},
+		{"x.h", "// -*- C++ -*-\n", "C++"},
+		{"x.conf", "line\nline\nline\nline\nline\nline\nline\nline\nline\nline\n# vim: ft=yaml\n", "YAML"},
+		{"x.conf", "line\nline\nline\nline\nline\n# vim: ft=yaml\nline\nline\nline\nline\nline\n", "INI"},
+		{"x.py", "# vim: ft=nosuchlanguage\n", "Python"},
+	}
+	for _, tt := range tests {
+		got := ""
+		if l := DetectLanguage(tt.path, []byte(tt.content)); l != nil {
+			got = l.Name
+		}
+		if got != tt.want {
+			t.Errorf("DetectLanguage(%q, %q) = %q, want %q", tt.path, tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestShebangInterpreter(t *testing.T) {
+	tests := []struct {
+		content, want string
+	}{
+		{"#!/bin/bash\n", "bash"},
+		{"#! /usr/bin/python3.11 -O\n", "python"},
+		{"#!/usr/bin/env node\n", "node"},
+		{"#!/usr/bin/env -S python3 -u\n", "python"},
+		{"#!/usr/bin/env -i LANG=C perl5.36\n", "perl"},
+		{"#!/usr/bin/env\n", ""},
+		{"#!\n", ""},
+		{"echo #!/bin/sh\n", ""},
+	}
+	for _, tt := range tests {
+		if got := shebangInterpreter([]byte(tt.content)); got != tt.want {
+			t.Errorf("shebangInterpreter(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterMerges(t *testing.T) {
+	table := newLanguageTable(builtinLanguages)
+	table.register(Language{Name: "html", Extensions: []string{"tpl"}, Aliases: []string{"gohtml"}})
+	table.register(Language{Name: "Jsonnet", Fence: "jsonnet", Extensions: []string{".jsonnet"}, LineComments: []string{"//"}, Quotes: []string{"|||"}})
+	table.register(Language{Name: "Jsonnet", Extensions: []string{".libsonnet"}, LineComments: []string{"#"}})
+	// A later definition takes over an extension claimed before.
+	table.register(Language{Name: "Templates", Extensions: []string{".htm"}})
+
+	html, _ := table.lookup("HTML")
+	if html.Name != "HTML" || !slices.Contains(html.Extensions, ".tpl") || !slices.Contains(html.Extensions, ".html") || html.Fence != "html" {
+		t.Errorf("merged HTML = %+v", html)
+	}
+	if l, _ := table.lookup("gohtml"); l != html {
+		t.Errorf("alias gohtml = %+v, want HTML", l)
+	}
+	for ext, want := range map[string]string{".tpl": "HTML", ".html": "HTML", ".jsonnet": "Jsonnet", ".libsonnet": "Jsonnet", ".htm": "Templates"} {
+		if l := table.byExtension[ext]; l == nil || l.Name != want {
+			t.Errorf("extension %s = %+v, want %s", ext, l, want)
+		}
+	}
+	jsonnet, _ := table.lookup("jsonnet")
+	if !slices.Equal(jsonnet.LineComments, []string{"//", "#"}) || !slices.Equal(jsonnet.Quotes, []string{"|||"}) {
+		t.Errorf("merged Jsonnet = %+v", jsonnet)
+	}
+	if l, _ := languages.lookup("Templates"); l != nil {
+		t.Error("registering in a table changed the global one")
+	}
+}
+
+func TestLoadLanguages(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name, content string
+		wantErr       bool
+	}{
+		{"ok.json", `[{"name": "Repogo Test Language", "extensions": [".repogotest"], "line_comments": [";;"]}]`, false},
+		{"unnamed.json", `[{"extensions": [".x"]}]`, true},
+		{"bad.json", `{"name": "not an array"}`, true},
+	}
+	for _, tt := range tests {
+		p := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(p, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := LoadLanguages(p); (err != nil) != tt.wantErr {
+			t.Errorf("LoadLanguages(%s) = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+	if err := LoadLanguages(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("LoadLanguages of a missing file succeeded")
+	}
+	if l := DetectLanguage("a.repogotest", nil); l == nil || l.Name != "Repogo Test Language" {
+		t.Errorf("DetectLanguage after LoadLanguages = %+v", l)
+	}
+}
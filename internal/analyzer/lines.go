@@ -0,0 +1,104 @@
+// Package analyzer provides file content analysis functionality.
+package analyzer
+
+import "strings"
+
+// LineCounts classifies the lines of a file.
+type LineCounts struct {
+	Code    int
+	Comment int
+	Blank   int
+}
+
+// Total returns the number of lines counted.
+func (c LineCounts) Total() int {
+	return c.Code + c.Comment + c.Blank
+}
+
+// CountLines classifies each line of content as code, comment or blank using
+// the comment syntax of lang. A line holding both code and a comment counts as
+// code. String literals are not parsed, so comment markers inside strings may
+// be misread; the result is an estimate. With a nil or comment-less language
+// every non-blank line counts as code.
+func CountLines(content string, lang *Language) LineCounts {
+	var c LineCounts
+	if content == "" {
+		return c
+	}
+	var lineMarkers []string
+	var blocks [][2]string
+	if lang != nil {
+		lineMarkers = lang.LineComments
+		blocks = lang.BlockComments
+	}
+
+	blockEnd := "" // closing delimiter while inside a block comment
+	for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			if blockEnd != "" {
+				c.Comment++
+			} else {
+				c.Blank++
+			}
+			continue
+		}
+
+		hasCode, hasComment := false, false
+		rest := trimmed
+		for rest != "" {
+			if blockEnd != "" {
+				hasComment = true
+				i := strings.Index(rest, blockEnd)
+				if i < 0 {
+					rest = ""
+					break
+				}
+				rest = strings.TrimSpace(rest[i+len(blockEnd):])
+				blockEnd = ""
+				continue
+			}
+			// Block openers are checked first so that "--[[" wins over "--".
+			if open, end, ok := blockStart(rest, blocks); ok {
+				hasComment = true
+				rest = rest[len(open):]
+				blockEnd = end
+				continue
+			}
+			if hasPrefixAny(rest, lineMarkers) {
+				hasComment = true
+				break
+			}
+			hasCode = true
+			rest = rest[1:]
+		}
+
+		switch {
+		case hasCode:
+			c.Code++
+		case hasComment:
+			c.Comment++
+		default:
+			c.Code++
+		}
+	}
+	return c
+}
+
+func hasPrefixAny(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func blockStart(s string, blocks [][2]string) (string, string, bool) {
+	for _, b := range blocks {
+		if strings.HasPrefix(s, b[0]) {
+			return b[0], b[1], true
+		}
+	}
+	return "", "", false
+}
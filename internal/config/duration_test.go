@@ -0,0 +1,51 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"90d", 90 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"1.5d", 36 * time.Hour, false},
+		{"36h", 36 * time.Hour, false},
+		{" 15m ", 15 * time.Minute, false},
+		{"d", 0, true},
+		{"soon", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"2d", now.Add(-48 * time.Hour), false},
+		{"2024-01-31", time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local), false},
+		{"2024-01-31T08:30:00", time.Date(2024, 1, 31, 8, 30, 0, 0, time.Local), false},
+		{"2024-01-31T08:30:00Z", time.Date(2024, 1, 31, 8, 30, 0, 0, time.UTC), false},
+		{"yesterday", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.in, now)
+		if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
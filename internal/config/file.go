@@ -0,0 +1,126 @@
+// Package config handles CLI flags and configuration.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileName is the name of the per-project configuration file.
+const FileName = ".repogo.json"
+
+// EnvFile names an environment variable that overrides the configuration
+// file location.
+const EnvFile = "REPOGO_CONFIG"
+
+// FilePath returns the configuration file in effect: $REPOGO_CONFIG if set,
+// otherwise ./.repogo.json, otherwise <user config dir>/repogo/config.json.
+// Returns "" if none of them exists.
+func FilePath() string {
+	if p := os.Getenv(EnvFile); p != "" {
+		return p
+	}
+	for _, p := range []string{FileName, GlobalFilePath()} {
+		if p == "" {
+			continue
+		}
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// GlobalFilePath returns the location of the per-user configuration file.
+func GlobalFilePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "repogo", "config.json")
+}
+
+// LoadFile reads a configuration file. The file is a JSON object mapping
+// flag names (without the leading dash) to a value or an array of values.
+func LoadFile(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	values := make(map[string][]string, len(raw))
+	for k, v := range raw {
+		switch v := v.(type) {
+		case []any:
+			parts := make([]string, len(v))
+			for i, p := range v {
+				parts[i] = fmt.Sprint(p)
+			}
+			values[k] = parts
+		case nil:
+			// Leave the flag at its default.
+		default:
+			values[k] = []string{fmt.Sprint(v)}
+		}
+	}
+	return values, nil
+}
+
+// ApplyFile sets the flags of set from the configuration file in effect, so
+// that command-line flags parsed afterwards override them. Arrays are joined
+// with commas, except for repeatable flags which receive each element in
+// turn. Keys naming flags that set does not define are ignored, as they may
+// belong to another command.
+func ApplyFile(set *flag.FlagSet) error {
+	path := FilePath()
+	if path == "" {
+		return nil
+	}
+	values, err := LoadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && os.Getenv(EnvFile) == "" {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		f := set.Lookup(k)
+		if f == nil {
+			continue
+		}
+		elems := values[k]
+		if _, repeatable := f.Value.(*listFlag); !repeatable {
+			elems = []string{strings.Join(elems, ",")}
+		}
+		for _, v := range elems {
+			if err := set.Set(k, v); err != nil {
+				return fmt.Errorf("%s: %s: %w", path, k, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Values returns the current value of every flag in set, keyed by flag name.
+func Values(set *flag.FlagSet) map[string]string {
+	values := map[string]string{}
+	set.VisitAll(func(f *flag.Flag) {
+		values[f.Name] = f.Value.String()
+	})
+	return values
+}
@@ -1,43 +1,210 @@
 // Package config handles CLI flags and configuration.
 package config
 
-import "flag"
+import (
+	"flag"
+	"strings"
+)
 
 // Version is the application version, can be overridden at build time.
 var Version = "0.1.0"
 
 // Config holds all configuration options for the application.
 type Config struct {
-	Version     *bool
-	Help        *bool
-	Output      *string
-	Include     *string
-	Exclude     *string
-	Format      *string
-	ShowTokens  *bool
-	MaxFileSize *int
-	MaxTokens   *int
-}
-
-// ParseFlags parses command-line flags and returns a Config.
-func ParseFlags() *Config {
-	cfg := &Config{
-		Version:     flag.Bool("v", false, "print version"),
-		Help:        flag.Bool("h", false, "show help"),
-		Output:      flag.String("o", "", "output file (default stdout)"),
-		Include:     flag.String("include", "", "comma-separated glob(s) to include (supports *, ?, [class])"),
-		Exclude:     flag.String("exclude", "", "comma-separated glob(s) to exclude (supports *, ?, [class])"),
-		Format:      flag.String("format", "markdown", "output format: markdown|json"),
-		ShowTokens:  flag.Bool("tokens", false, "print estimated token count"),
-		MaxFileSize: flag.Int("max-file-size", 16*1024, "per-file size limit in bytes before truncation"),
-		MaxTokens:   flag.Int("max-tokens", 0, "stop when total estimated tokens reach this number (0 = no limit)"),
+	Output          string
+	Include         string
+	Exclude         string
+	Preset          string
+	Format          string
+	ShowTokens      bool
+	MaxFileSize     int
+	MaxTokens       int
+	Dedupe          bool
+	DedupeThreshold float64
+	Compress        string
+	LineNumbers     bool
+	Languages       string
+	Submodules      string
+	NestedGit       bool
+	Symlinks        string
+	Generated       string
+	SymlinksOutside bool
+	NewerThan       string
+	OlderThan       string
+	MinSize         string
+	MaxSize         string
+	MaxDepth        int
+	FilesFrom       string
+	Explain         bool
+	Stats           bool
+	Deps            bool
+	History         bool
+	HistoryWindow   string
+	Sort            string
+	Log             int
+	LogStats        bool
+	LogPacked       bool
+	Query           string
+	FollowImports   string
+	ReverseDeps     bool
+	ImportGraph     bool
+	Grep            []string
+	GrepMode        string
+	GrepContext     int
+	TreeAnnotate    bool
+	TreeDepth       int
+	TreeStyle       string
+	DiffBase        string
+	DiffStaged      bool
+	ServeAddr       string
+	ServeRoots      string
+}
+
+// Default returns a Config holding the default value of every option.
+func Default() *Config {
+	return &Config{
+		Format:          "markdown",
+		MaxFileSize:     16 * 1024,
+		Deps:            true,
+		Submodules:      "stub",
+		Symlinks:        "follow",
+		Generated:       "stub",
+		Sort:            "path",
+		DedupeThreshold: 0.9,
+		Compress:        "none",
+		GrepMode:        "any",
+		GrepContext:     -1,
+		TreeStyle:       "indent",
+		DiffBase:        "HEAD",
+		ServeAddr:       "127.0.0.1:8080",
+		ServeRoots:      ".",
 	}
+}
+
+// RegisterOutput binds the output flags to fs.
+func (c *Config) RegisterOutput(fs *flag.FlagSet) {
+	fs.StringVar(&c.Output, "o", c.Output, "output file (default stdout)")
+	fs.StringVar(&c.Format, "format", c.Format, "output format: markdown|json")
+}
+
+// RegisterScan binds the file selection and reading flags to fs.
+func (c *Config) RegisterScan(fs *flag.FlagSet) {
+	fs.StringVar(&c.Include, "include", c.Include, "comma-separated glob(s) to include (supports *, ?, [class])")
+	fs.StringVar(&c.Exclude, "exclude", c.Exclude, "comma-separated glob(s) to exclude (supports *, ?, [class])")
+	fs.StringVar(&c.Preset, "preset", c.Preset, "comma-separated ecosystem presets adding default excludes (go, node, python, java, rust, or auto to detect them)")
+	fs.IntVar(&c.MaxFileSize, "max-file-size", c.MaxFileSize, "per-file size limit in bytes before truncation")
+	fs.StringVar(&c.Languages, "languages", c.Languages, "JSON file with extra language definitions (extensions, filenames, interpreters)")
+	fs.StringVar(&c.Submodules, "submodules", c.Submodules, "how to scan git submodules: stub|include|skip")
+	fs.BoolVar(&c.NestedGit, "nested-git", c.NestedGit, "also scan the .git entries of submodules and nested repositories")
+	fs.StringVar(&c.Generated, "generated", c.Generated, "generated, minified and vendored files: stub|skip|include")
+	fs.StringVar(&c.Symlinks, "symlinks", c.Symlinks, "how to treat symbolic links: follow|skip|record")
+	fs.BoolVar(&c.SymlinksOutside, "symlinks-outside", c.SymlinksOutside, "follow symbolic links whose target is outside the root")
+	fs.StringVar(&c.NewerThan, "newer-than", c.NewerThan, "keep only files modified within this duration or since this date, e.g. 7d or 2024-01-31")
+	fs.StringVar(&c.OlderThan, "older-than", c.OlderThan, "keep only files not modified within this duration or since this date")
+	fs.StringVar(&c.MinSize, "min-size", c.MinSize, "exclude files smaller than this size, e.g. 10 or 1KB")
+	fs.StringVar(&c.MaxSize, "max-size", c.MaxSize, "exclude (rather than truncate) files larger than this size, e.g. 200KB")
+	fs.IntVar(&c.MaxDepth, "max-depth", c.MaxDepth, "scan at most this many directory levels below the root (0 = unlimited)")
+}
+
+// RegisterFilesFrom binds the flag reading the paths to pack from a list to fs.
+func (c *Config) RegisterFilesFrom(fs *flag.FlagSet) {
+	fs.StringVar(&c.FilesFrom, "files-from", c.FilesFrom, "pack the newline- or NUL-separated paths listed in this file (- for stdin) instead of walking; a single argument sets the root")
+}
+
+// RegisterExplain binds the flag switching to explain mode to fs.
+func (c *Config) RegisterExplain(fs *flag.FlagSet) {
+	fs.BoolVar(&c.Explain, "explain", c.Explain, "instead of packing, list every candidate path with the decision taken and the rule responsible")
+}
+
+// RegisterImports binds the Go import graph flags to fs.
+func (c *Config) RegisterImports(fs *flag.FlagSet) {
+	fs.StringVar(&c.FollowImports, "follow-imports", c.FollowImports, "comma-separated Go packages or files to pack together with the in-module packages they import")
+	fs.BoolVar(&c.ReverseDeps, "reverse-deps", c.ReverseDeps, "with -follow-imports, also pack the packages that import them")
+	fs.BoolVar(&c.ImportGraph, "import-graph", c.ImportGraph, "add the Go package dependency graph to the structure section")
+}
+
+// RegisterGrep binds the content search flags to fs.
+func (c *Config) RegisterGrep(fs *flag.FlagSet) {
+	fs.Var((*listFlag)(&c.Grep), "grep", "keep only files whose content matches this regular expression (repeatable)")
+	fs.StringVar(&c.GrepMode, "grep-mode", c.GrepMode, "how several -grep patterns combine: any|all")
+	fs.IntVar(&c.GrepContext, "context", c.GrepContext, "emit only the lines matching -grep with this many lines of context (-1 = whole files)")
+}
 
-	flag.Parse()
-	return cfg
+// RegisterPack binds the flags that control packed output to fs.
+func (c *Config) RegisterPack(fs *flag.FlagSet) {
+	fs.BoolVar(&c.ShowTokens, "tokens", c.ShowTokens, "print estimated token count")
+	fs.IntVar(&c.MaxTokens, "max-tokens", c.MaxTokens, "stop when total estimated tokens reach this number (0 = no limit)")
+	fs.BoolVar(&c.Dedupe, "dedupe", c.Dedupe, "pack identical files once and near-duplicates as diffs against the first copy")
+	fs.Float64Var(&c.DedupeThreshold, "dedupe-threshold", c.DedupeThreshold, "with -dedupe, share of common lines from which files count as near-duplicates (1 = identical only)")
+	fs.StringVar(&c.Compress, "compress", c.Compress, "shrink packed code (each level adds to the previous): none|whitespace|comments|indent")
+	fs.BoolVar(&c.LineNumbers, "line-numbers", c.LineNumbers, "number lines as in the original file (JSON lists line ranges instead)")
+	fs.BoolVar(&c.Stats, "stats", c.Stats, "append per-language and per-directory statistics")
+	fs.BoolVar(&c.Deps, "deps", c.Deps, "summarize dependency manifests and lock files (-deps=false packs lock files verbatim)")
+	fs.StringVar(&c.Sort, "sort", c.Sort, "order of packed files: path|churn")
+	fs.StringVar(&c.Query, "query", c.Query, "order files by relevance to this text (BM25 over paths, identifiers and content)")
 }
 
-// Args returns the non-flag arguments.
-func Args() []string {
-	return flag.Args()
+// RegisterHistory binds the per-file git history flags to fs.
+func (c *Config) RegisterHistory(fs *flag.FlagSet) {
+	fs.BoolVar(&c.History, "history", c.History, "annotate files with their last commit and commit/author counts")
+	fs.StringVar(&c.HistoryWindow, "history-window", c.HistoryWindow, "count commits and authors since this duration ago or date, e.g. 90d or 2024-01-01 (default all history)")
 }
+
+// RegisterLog binds the commit history flags to fs.
+func (c *Config) RegisterLog(fs *flag.FlagSet) {
+	fs.IntVar(&c.Log, "log", c.Log, "add the last N commits to the output (0 = none)")
+	fs.BoolVar(&c.LogStats, "log-stat", c.LogStats, "list the files each commit changed with added/deleted lines")
+	fs.BoolVar(&c.LogPacked, "log-packed", c.LogPacked, "only list commits that touch the packed files")
+}
+
+// RegisterTree binds the directory structure flags to fs.
+func (c *Config) RegisterTree(fs *flag.FlagSet) {
+	fs.BoolVar(&c.TreeAnnotate, "tree-annotate", c.TreeAnnotate, "annotate the structure with size, lines and estimated tokens")
+	fs.IntVar(&c.TreeDepth, "tree-depth", c.TreeDepth, "collapse structure levels deeper than this (0 = no limit)")
+	fs.StringVar(&c.TreeStyle, "tree-style", c.TreeStyle, "structure style: indent|ascii|unicode")
+}
+
+// RegisterDiff binds the flags of the diff command to fs.
+func (c *Config) RegisterDiff(fs *flag.FlagSet) {
+	fs.StringVar(&c.DiffBase, "base", c.DiffBase, "git revision to compare the working tree against")
+	fs.BoolVar(&c.DiffStaged, "staged", c.DiffStaged, "compare the index instead of the working tree")
+}
+
+// RegisterServe binds the flags of the serve command to fs.
+func (c *Config) RegisterServe(fs *flag.FlagSet) {
+	fs.StringVar(&c.ServeAddr, "addr", c.ServeAddr, "address to listen on")
+	fs.StringVar(&c.ServeRoots, "roots", c.ServeRoots, "comma-separated directories clients may read from")
+}
+
+// Parse parses args into fs. Unlike flag.FlagSet.Parse, flags may follow
+// positional arguments ("repogo . -o out.md"); everything after "--" is
+// positional. It returns the positional arguments.
+func Parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// listFlag is a repeatable string flag: every occurrence adds one value.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+func (l *listFlag) Get() any { return []string(*l) }
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newPackFlags(cfg *Config) *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	cfg.RegisterOutput(fs)
+	cfg.RegisterScan(fs)
+	cfg.RegisterGrep(fs)
+	cfg.RegisterPack(fs)
+	return fs
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		positional []string
+		output     string
+		maxTokens  int
+	}{
+		{"flags first", []string{"-o", "out.md", "src"}, []string{"src"}, "out.md", 0},
+		{"flags after paths", []string{"src", "-o", "out.md", "lib"}, []string{"src", "lib"}, "out.md", 0},
+		{"interleaved", []string{"a", "-max-tokens", "10", "b", "-o=x"}, []string{"a", "b"}, "x", 10},
+		{"double dash", []string{"-o", "x", "--", "-not-a-flag", "b"}, []string{"-not-a-flag", "b"}, "x", 0},
+		{"no arguments", nil, nil, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			positional, err := Parse(newPackFlags(cfg), tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(positional, tt.positional) {
+				t.Errorf("positional = %q, want %q", positional, tt.positional)
+			}
+			if cfg.Output != tt.output || cfg.MaxTokens != tt.maxTokens {
+				t.Errorf("Output, MaxTokens = %q, %d; want %q, %d", cfg.Output, cfg.MaxTokens, tt.output, tt.maxTokens)
+			}
+		})
+	}
+}
+
+func TestParseUnknownFlag(t *testing.T) {
+	if _, err := Parse(newPackFlags(Default()), []string{".", "-no-such-flag"}); err == nil {
+		t.Error("Parse accepted an undefined flag")
+	}
+}
+
+func TestApplyFilePrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{
+		"format": "json",
+		"max-tokens": 5000,
+		"exclude": ["vendor", "*_test.go"],
+		"grep": ["TODO", "FIXME"],
+		"o": null,
+		"addr": ":9999"
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvFile, path)
+
+	tests := []struct {
+		name      string
+		args      []string
+		format    string
+		maxTokens int
+		exclude   string
+		grep      []string
+	}{
+		{"file only", nil, "json", 5000, "vendor,*_test.go", []string{"TODO", "FIXME"}},
+		{"flags override the file", []string{"-format", "markdown", "-max-tokens", "10"}, "markdown", 10, "vendor,*_test.go", []string{"TODO", "FIXME"}},
+		{"repeatable flags accumulate", []string{"-grep", "XXX"}, "json", 5000, "vendor,*_test.go", []string{"TODO", "FIXME", "XXX"}},
+		{"list flag replaced", []string{"-exclude", "dist"}, "json", 5000, "dist", []string{"TODO", "FIXME"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			fs := newPackFlags(cfg)
+			if err := ApplyFile(fs); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Parse(fs, tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Format != tt.format || cfg.MaxTokens != tt.maxTokens || cfg.Exclude != tt.exclude {
+				t.Errorf("Format, MaxTokens, Exclude = %q, %d, %q; want %q, %d, %q",
+					cfg.Format, cfg.MaxTokens, cfg.Exclude, tt.format, tt.maxTokens, tt.exclude)
+			}
+			if !reflect.DeepEqual(cfg.Grep, tt.grep) {
+				t.Errorf("Grep = %q, want %q", cfg.Grep, tt.grep)
+			}
+			if cfg.Output != "" {
+				t.Errorf("Output = %q, want the default for a null value", cfg.Output)
+			}
+		})
+	}
+}
+
+func TestApplyFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"max-tokens": "many"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	broken := filepath.Join(dir, "broken.json")
+	if err := os.WriteFile(broken, []byte(`{`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{bad, broken, filepath.Join(dir, "missing.json")} {
+		t.Setenv(EnvFile, path)
+		if err := ApplyFile(newPackFlags(Default())); err == nil {
+			t.Errorf("ApplyFile(%s) succeeded, want an error", filepath.Base(path))
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Package config handles CLI flags and configuration.
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits are the suffixes accepted by ParseSize, longest first.
+var sizeUnits = []struct {
+	suffix string
+	factor float64
+}{
+	{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30},
+	{"kb", 1 << 10}, {"mb", 1 << 20}, {"gb", 1 << 30},
+	{"k", 1 << 10}, {"m", 1 << 20}, {"g", 1 << 30},
+	{"b", 1},
+}
+
+// ParseSize parses a byte count such as "4096", "200KB" or "1.5M". The K,
+// M and G suffixes, optionally followed by B or iB, are powers of 1024.
+// The empty string yields 0.
+func ParseSize(s string) (int64, error) {
+	num := strings.ToLower(strings.TrimSpace(s))
+	if num == "" {
+		return 0, nil
+	}
+	factor := 1.0
+	for _, u := range sizeUnits {
+		if n, ok := strings.CutSuffix(num, u.suffix); ok {
+			num, factor = strings.TrimSpace(n), u.factor
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid size %q (want a byte count such as 4096, 200KB or 1.5MB)", s)
+	}
+	return int64(f * factor), nil
+}
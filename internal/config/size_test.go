@@ -0,0 +1,29 @@
+package config
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"4096", 4096, false},
+		{"512b", 512, false},
+		{"200KB", 200 << 10, false},
+		{"200 kb", 200 << 10, false},
+		{"1.5M", 3 << 19, false},
+		{"2MiB", 2 << 20, false},
+		{"1g", 1 << 30, false},
+		{"-1", 0, true},
+		{"KB", 0, true},
+		{"10 bytes", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
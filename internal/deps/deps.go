@@ -0,0 +1,319 @@
+// Package deps summarizes dependency manifests and lock files.
+package deps
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/models"
+)
+
+// Ecosystem names used in manifests and lock files.
+const (
+	Go     = "Go"
+	NPM    = "npm"
+	Python = "Python"
+	Cargo  = "Cargo"
+	Maven  = "Maven"
+)
+
+// manifests and lockFiles map base names to their parsers.
+var (
+	manifests = map[string]func([]byte) (*models.Manifest, error){
+		"go.mod":           parseGoMod,
+		"package.json":     parsePackageJSON,
+		"requirements.txt": parseRequirements,
+		"pyproject.toml":   parsePyproject,
+		"Cargo.toml":       parseCargo,
+		"pom.xml":          parsePom,
+	}
+	lockFiles = map[string]func([]byte) *models.LockFile{
+		"go.sum":            countGoSum,
+		"package-lock.json": countPackageLock,
+		"yarn.lock":         countYarnLock,
+		"Cargo.lock":        countTOMLPackages(Cargo),
+		"poetry.lock":       countTOMLPackages(Python),
+	}
+)
+
+// IsManifest reports whether relPath names a recognized manifest.
+func IsManifest(relPath string) bool {
+	_, ok := manifests[path.Base(relPath)]
+	return ok
+}
+
+// IsLockFile reports whether relPath names a recognized lock file.
+func IsLockFile(relPath string) bool {
+	_, ok := lockFiles[path.Base(relPath)]
+	return ok
+}
+
+// ParseManifest extracts the direct dependencies declared in a manifest.
+func ParseManifest(relPath string, content []byte) (*models.Manifest, error) {
+	parse, ok := manifests[path.Base(relPath)]
+	if !ok {
+		return nil, nil
+	}
+	m, err := parse(content)
+	if err != nil {
+		return nil, err
+	}
+	m.Path = relPath
+	return m, nil
+}
+
+// SummarizeLockFile counts the packages pinned by a lock file.
+func SummarizeLockFile(relPath string, content []byte) *models.LockFile {
+	count, ok := lockFiles[path.Base(relPath)]
+	if !ok {
+		return nil
+	}
+	l := count(content)
+	l.Path = relPath
+	return l
+}
+
+func parseGoMod(content []byte) (*models.Manifest, error) {
+	m := &models.Manifest{Ecosystem: Go}
+	inRequire := false
+	sc := bufio.NewScanner(strings.NewReader(string(content)))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		comment := ""
+		if i := strings.Index(line, "//"); i >= 0 {
+			line, comment = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+2:])
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case inRequire && fields[0] == ")":
+			inRequire = false
+			continue
+		case fields[0] == "module" && len(fields) > 1:
+			m.Name = strings.Trim(fields[1], `"`)
+			continue
+		case fields[0] == "require" && len(fields) > 1 && fields[1] == "(":
+			inRequire = true
+			continue
+		case fields[0] == "require":
+			fields = fields[1:]
+		case !inRequire:
+			continue
+		}
+		if len(fields) < 2 {
+			continue
+		}
+		d := models.Dependency{Name: strings.Trim(fields[0], `"`), Version: fields[1]}
+		if comment == "indirect" {
+			d.Scope = "indirect"
+		}
+		m.Dependencies = append(m.Dependencies, d)
+	}
+	return m, sc.Err()
+}
+
+func parsePackageJSON(content []byte) (*models.Manifest, error) {
+	var pkg struct {
+		Name                 string            `json:"name"`
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		return nil, err
+	}
+	m := &models.Manifest{Ecosystem: NPM, Name: pkg.Name}
+	m.Dependencies = append(m.Dependencies, fromMap(pkg.Dependencies, "")...)
+	m.Dependencies = append(m.Dependencies, fromMap(pkg.DevDependencies, "dev")...)
+	m.Dependencies = append(m.Dependencies, fromMap(pkg.PeerDependencies, "peer")...)
+	m.Dependencies = append(m.Dependencies, fromMap(pkg.OptionalDependencies, "optional")...)
+	return m, nil
+}
+
+func fromMap(deps map[string]string, scope string) []models.Dependency {
+	out := make([]models.Dependency, 0, len(deps))
+	for name, version := range deps {
+		out = append(out, models.Dependency{Name: name, Version: version, Scope: scope})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func parseRequirements(content []byte) (*models.Manifest, error) {
+	m := &models.Manifest{Ecosystem: Python}
+	sc := bufio.NewScanner(strings.NewReader(string(content)))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || line[0] == '#' || line[0] == '-' {
+			continue // comments and options such as -r, -e, --index-url
+		}
+		m.Dependencies = append(m.Dependencies, pep508(line, ""))
+	}
+	return m, sc.Err()
+}
+
+// pep508 splits a Python requirement such as "requests[socks]>=2.0; python_version<'3.8'"
+// into name and version specifier.
+func pep508(req, scope string) models.Dependency {
+	if i := strings.Index(req, ";"); i >= 0 {
+		req = req[:i]
+	}
+	req = strings.TrimSpace(req)
+	end := strings.IndexAny(req, "=<>!~ [(@")
+	if end < 0 {
+		return models.Dependency{Name: req, Scope: scope}
+	}
+	name, rest := req[:end], req[end:]
+	if strings.HasPrefix(rest, "[") {
+		if i := strings.Index(rest, "]"); i >= 0 {
+			rest = rest[i+1:]
+		}
+	}
+	rest = strings.Trim(strings.TrimSpace(rest), "()")
+	return models.Dependency{Name: name, Version: strings.TrimSpace(rest), Scope: scope}
+}
+
+func parsePyproject(content []byte) (*models.Manifest, error) {
+	m := &models.Manifest{Ecosystem: Python}
+	for _, e := range parseTOML(string(content)) {
+		switch {
+		case e.table == "project" && e.key == "name", e.table == "tool.poetry" && e.key == "name":
+			m.Name = tomlString(e.value)
+		case e.table == "project" && e.key == "dependencies":
+			for _, req := range tomlArray(e.value) {
+				m.Dependencies = append(m.Dependencies, pep508(req, ""))
+			}
+		case e.table == "project.optional-dependencies":
+			for _, req := range tomlArray(e.value) {
+				m.Dependencies = append(m.Dependencies, pep508(req, e.key))
+			}
+		case e.table == "tool.poetry.dependencies" && e.key != "python":
+			m.Dependencies = append(m.Dependencies, models.Dependency{Name: e.key, Version: tomlVersion(e.value)})
+		case strings.HasPrefix(e.table, "tool.poetry.group.") && strings.HasSuffix(e.table, ".dependencies"):
+			group := strings.TrimSuffix(strings.TrimPrefix(e.table, "tool.poetry.group."), ".dependencies")
+			m.Dependencies = append(m.Dependencies, models.Dependency{Name: e.key, Version: tomlVersion(e.value), Scope: group})
+		case e.table == "tool.poetry.dev-dependencies":
+			m.Dependencies = append(m.Dependencies, models.Dependency{Name: e.key, Version: tomlVersion(e.value), Scope: "dev"})
+		}
+	}
+	return m, nil
+}
+
+func parseCargo(content []byte) (*models.Manifest, error) {
+	m := &models.Manifest{Ecosystem: Cargo}
+	scopes := map[string]string{"dependencies": "", "dev-dependencies": "dev", "build-dependencies": "build"}
+	for _, e := range parseTOML(string(content)) {
+		if e.table == "package" && e.key == "name" {
+			m.Name = tomlString(e.value)
+			continue
+		}
+		if scope, ok := scopes[e.table]; ok {
+			m.Dependencies = append(m.Dependencies, models.Dependency{Name: e.key, Version: tomlVersion(e.value), Scope: scope})
+			continue
+		}
+		// [dependencies.serde] version = "1.0"
+		for table, scope := range scopes {
+			if name, ok := strings.CutPrefix(e.table, table+"."); ok && e.key == "version" {
+				m.Dependencies = append(m.Dependencies, models.Dependency{Name: name, Version: tomlString(e.value), Scope: scope})
+			}
+		}
+	}
+	return m, nil
+}
+
+func parsePom(content []byte) (*models.Manifest, error) {
+	var pom struct {
+		GroupID      string `xml:"groupId"`
+		ArtifactID   string `xml:"artifactId"`
+		Dependencies []struct {
+			GroupID    string `xml:"groupId"`
+			ArtifactID string `xml:"artifactId"`
+			Version    string `xml:"version"`
+			Scope      string `xml:"scope"`
+		} `xml:"dependencies>dependency"`
+	}
+	if err := xml.Unmarshal(content, &pom); err != nil {
+		return nil, err
+	}
+	m := &models.Manifest{Ecosystem: Maven, Name: strings.Trim(pom.GroupID+":"+pom.ArtifactID, ":")}
+	for _, d := range pom.Dependencies {
+		m.Dependencies = append(m.Dependencies, models.Dependency{
+			Name:    d.GroupID + ":" + d.ArtifactID,
+			Version: d.Version,
+			Scope:   d.Scope,
+		})
+	}
+	return m, nil
+}
+
+// countGoSum counts module versions; each appears once for its content
+// hash and once for its go.mod hash.
+func countGoSum(content []byte) *models.LockFile {
+	seen := map[string]bool{}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 {
+			seen[fields[0]+" "+strings.TrimSuffix(fields[1], "/go.mod")] = true
+		}
+	}
+	return &models.LockFile{Ecosystem: Go, Packages: len(seen)}
+}
+
+func countPackageLock(content []byte) *models.LockFile {
+	var lock struct {
+		Packages     map[string]json.RawMessage `json:"packages"`
+		Dependencies map[string]json.RawMessage `json:"dependencies"`
+	}
+	l := &models.LockFile{Ecosystem: NPM}
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return l
+	}
+	if len(lock.Packages) > 0 {
+		for key := range lock.Packages {
+			if key != "" { // "" is the root project
+				l.Packages++
+			}
+		}
+		return l
+	}
+	l.Packages = len(lock.Dependencies)
+	return l
+}
+
+// countYarnLock counts entries: unindented lines ending in a colon, as in
+// `lodash@^4.17.0, lodash@^4.17.21:`.
+func countYarnLock(content []byte) *models.LockFile {
+	l := &models.LockFile{Ecosystem: NPM}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" || line[0] == ' ' || line[0] == '#' || line == "__metadata:" {
+			continue
+		}
+		if strings.HasSuffix(line, ":") {
+			l.Packages++
+		}
+	}
+	return l
+}
+
+// countTOMLPackages counts [[package]] tables, as used by Cargo.lock and poetry.lock.
+func countTOMLPackages(ecosystem string) func([]byte) *models.LockFile {
+	return func(content []byte) *models.LockFile {
+		l := &models.LockFile{Ecosystem: ecosystem}
+		for _, line := range strings.Split(string(content), "\n") {
+			if strings.TrimSpace(line) == "[[package]]" {
+				l.Packages++
+			}
+		}
+		return l
+	}
+}
@@ -0,0 +1,192 @@
+package deps
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/models"
+)
+
+func dep(name, version, scope string) models.Dependency {
+	return models.Dependency{Name: name, Version: version, Scope: scope}
+}
+
+func TestParseManifest(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+		want    *models.Manifest
+	}{
+		{
+			path: "go.mod",
+			content: `module example.com/demo
+
+go 1.22
+
+require github.com/a/b v1.2.3
+
+require (
+	github.com/c/d v0.1.0 // indirect
+	"github.com/e/f" v2.0.0+incompatible
+)
+`,
+			want: &models.Manifest{Path: "go.mod", Ecosystem: Go, Name: "example.com/demo", Dependencies: []models.Dependency{
+				dep("github.com/a/b", "v1.2.3", ""),
+				dep("github.com/c/d", "v0.1.0", "indirect"),
+				dep("github.com/e/f", "v2.0.0+incompatible", ""),
+			}},
+		},
+		{
+			path:    "web/package.json",
+			content: `{"name": "web", "dependencies": {"react": "^18.2.0", "axios": "1.6.0"}, "devDependencies": {"vite": "^5.0.0"}}`,
+			want: &models.Manifest{Path: "web/package.json", Ecosystem: NPM, Name: "web", Dependencies: []models.Dependency{
+				dep("axios", "1.6.0", ""),
+				dep("react", "^18.2.0", ""),
+				dep("vite", "^5.0.0", "dev"),
+			}},
+		},
+		{
+			path: "requirements.txt",
+			content: `# pinned
+requests[socks]>=2.31 ; python_version >= "3.8"
+Django==4.2  # LTS
+-r dev.txt
+--index-url https://example.com/simple
+flask
+numpy (>=1.26)
+`,
+			want: &models.Manifest{Path: "requirements.txt", Ecosystem: Python, Dependencies: []models.Dependency{
+				dep("requests", ">=2.31", ""),
+				dep("Django", "==4.2", ""),
+				dep("flask", "", ""),
+				dep("numpy", ">=1.26", ""),
+			}},
+		},
+		{
+			path: "pyproject.toml",
+			content: `[project]
+name = "tool"
+dependencies = [
+    "httpx>=0.27",
+    "rich",
+]
+
+[project.optional-dependencies]
+test = ["pytest>=8"]
+
+[tool.poetry.dependencies]
+python = "^3.11"
+pydantic = { version = "^2.5", extras = ["email"] }
+
+[tool.poetry.group.docs.dependencies]
+mkdocs = "*"
+`,
+			want: &models.Manifest{Path: "pyproject.toml", Ecosystem: Python, Name: "tool", Dependencies: []models.Dependency{
+				dep("httpx", ">=0.27", ""),
+				dep("rich", "", ""),
+				dep("pytest", ">=8", "test"),
+				dep("pydantic", "^2.5", ""),
+				dep("mkdocs", "*", "docs"),
+			}},
+		},
+		{
+			path: "Cargo.toml",
+			content: `[package]
+name = "crate"
+
+[dependencies]
+serde = { version = "1", features = ["derive"] }
+anyhow = "1.0"
+
+[dev-dependencies]
+proptest = "1.4"
+
+[build-dependencies.cc]
+version = "1.0"
+`,
+			want: &models.Manifest{Path: "Cargo.toml", Ecosystem: Cargo, Name: "crate", Dependencies: []models.Dependency{
+				dep("serde", "1", ""),
+				dep("anyhow", "1.0", ""),
+				dep("proptest", "1.4", "dev"),
+				dep("cc", "1.0", "build"),
+			}},
+		},
+		{
+			path: "pom.xml",
+			content: `<?xml version="1.0"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <dependencies>
+    <dependency>
+      <groupId>org.slf4j</groupId>
+      <artifactId>slf4j-api</artifactId>
+      <version>2.0.9</version>
+    </dependency>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <version>4.13.2</version>
+      <scope>test</scope>
+    </dependency>
+  </dependencies>
+</project>
+`,
+			want: &models.Manifest{Path: "pom.xml", Ecosystem: Maven, Name: "com.example:app", Dependencies: []models.Dependency{
+				dep("org.slf4j:slf4j-api", "2.0.9", ""),
+				dep("junit:junit", "4.13.2", "test"),
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if !IsManifest(tt.path) {
+				t.Fatalf("IsManifest(%s) = false", tt.path)
+			}
+			got, err := ParseManifest(tt.path, []byte(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseManifest =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseManifestErrors(t *testing.T) {
+	for _, path := range []string{"package.json", "pom.xml"} {
+		if _, err := ParseManifest(path, []byte("{<")); err == nil {
+			t.Errorf("ParseManifest(%s) accepted invalid content", path)
+		}
+	}
+	if m, err := ParseManifest("README.md", []byte("# x")); m != nil || err != nil {
+		t.Errorf("ParseManifest(README.md) = %v, %v; want nil, nil", m, err)
+	}
+}
+
+func TestSummarizeLockFile(t *testing.T) {
+	tests := []struct {
+		path      string
+		content   string
+		ecosystem string
+		packages  int
+	}{
+		{"go.sum", "github.com/a/b v1.0.0 h1:x=\ngithub.com/a/b v1.0.0/go.mod h1:y=\ngithub.com/c/d v0.2.0/go.mod h1:z=\n", Go, 2},
+		{"package-lock.json", `{"packages": {"": {}, "node_modules/a": {}, "node_modules/b": {}}}`, NPM, 2},
+		{"package-lock.json", `{"dependencies": {"a": {}, "b": {}, "c": {}}}`, NPM, 3},
+		{"yarn.lock", "# yarn lockfile v1\n\nlodash@^4.17.0, lodash@^4.17.21:\n  version \"4.17.21\"\n\n\"@babel/core@^7.0.0\":\n  version \"7.23.0\"\n", NPM, 2},
+		{"Cargo.lock", "version = 3\n\n[[package]]\nname = \"a\"\n\n[[package]]\nname = \"b\"\n", Cargo, 2},
+		{"poetry.lock", "[[package]]\nname = \"requests\"\n", Python, 1},
+	}
+	for _, tt := range tests {
+		if !IsLockFile(tt.path) {
+			t.Errorf("IsLockFile(%s) = false", tt.path)
+			continue
+		}
+		l := SummarizeLockFile("sub/"+tt.path, []byte(tt.content))
+		if l.Path != "sub/"+tt.path || l.Ecosystem != tt.ecosystem || l.Packages != tt.packages {
+			t.Errorf("SummarizeLockFile(%s) = %+v, want %s with %d packages", tt.path, *l, tt.ecosystem, tt.packages)
+		}
+	}
+}
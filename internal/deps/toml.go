@@ -0,0 +1,192 @@
+// Package deps summarizes dependency manifests and lock files.
+package deps
+
+import (
+	"strconv"
+	"strings"
+)
+
+// tomlEntry is one key/value pair of a TOML document. value is the raw,
+// unparsed value text, which may span several lines for arrays.
+type tomlEntry struct {
+	table string
+	key   string
+	value string
+}
+
+// parseTOML is a minimal reader for the subset of TOML used by manifests:
+// [table] and [[array]] headers and key = value pairs whose values are
+// strings, arrays or inline tables.
+func parseTOML(content string) []tomlEntry {
+	var entries []tomlEntry
+	table := ""
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(stripComment(lines[i]))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			table = strings.TrimSpace(strings.Trim(line, "[]"))
+			continue
+		}
+		eq := indexOutsideQuotes(line, '=')
+		if eq < 0 {
+			continue
+		}
+		key := unquoteKey(strings.TrimSpace(line[:eq]))
+		value := strings.TrimSpace(line[eq+1:])
+		// Multi-line arrays and inline tables continue until brackets balance.
+		for depth(value) > 0 && i+1 < len(lines) {
+			i++
+			value += " " + strings.TrimSpace(stripComment(lines[i]))
+		}
+		entries = append(entries, tomlEntry{table: table, key: key, value: value})
+	}
+	return entries
+}
+
+// stripComment removes a trailing # comment outside of strings.
+func stripComment(line string) string {
+	if i := indexOutsideQuotes(line, '#'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// indexOutsideQuotes returns the index of the first c not inside a string.
+func indexOutsideQuotes(s string, c byte) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == '\\' && quote == '"' {
+				i++
+			} else if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == c:
+			return i
+		}
+	}
+	return -1
+}
+
+// depth returns the number of unclosed brackets and braces in s.
+func depth(s string) int {
+	d := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == '\\' && quote == '"' {
+				i++
+			} else if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == '[' || s[i] == '{':
+			d++
+		case s[i] == ']' || s[i] == '}':
+			d--
+		}
+	}
+	return d
+}
+
+func unquoteKey(k string) string {
+	if len(k) >= 2 && (k[0] == '"' || k[0] == '\'') {
+		return tomlString(k)
+	}
+	return k
+}
+
+// tomlString returns the value of a basic or literal string.
+func tomlString(v string) string {
+	v = strings.TrimSpace(v)
+	if len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'' {
+		return v[1 : len(v)-1]
+	}
+	if s, err := strconv.Unquote(v); err == nil {
+		return s
+	}
+	return strings.Trim(v, `"'`)
+}
+
+// tomlArray returns the string elements of an array value.
+func tomlArray(v string) []string {
+	v = strings.TrimSpace(v)
+	if !strings.HasPrefix(v, "[") {
+		return nil
+	}
+	var out []string
+	for _, item := range splitTopLevel(v[1 : len(v)-1]) {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, tomlString(item))
+		}
+	}
+	return out
+}
+
+// tomlInlineTable returns the keys and raw values of an inline table.
+func tomlInlineTable(v string) map[string]string {
+	v = strings.TrimSpace(v)
+	if !strings.HasPrefix(v, "{") {
+		return nil
+	}
+	out := map[string]string{}
+	for _, item := range splitTopLevel(v[1 : len(v)-1]) {
+		if eq := indexOutsideQuotes(item, '='); eq >= 0 {
+			out[unquoteKey(strings.TrimSpace(item[:eq]))] = strings.TrimSpace(item[eq+1:])
+		}
+	}
+	return out
+}
+
+// tomlVersion returns the version of a dependency given either as a string
+// or as an inline table; path and git dependencies report their source.
+func tomlVersion(v string) string {
+	t := tomlInlineTable(v)
+	if t == nil {
+		return tomlString(v)
+	}
+	for _, key := range []string{"version", "git", "path"} {
+		if raw, ok := t[key]; ok {
+			if key == "version" {
+				return tomlString(raw)
+			}
+			return key + ":" + tomlString(raw)
+		}
+	}
+	return ""
+}
+
+// splitTopLevel splits s at commas outside strings, arrays and tables.
+func splitTopLevel(s string) []string {
+	var parts []string
+	start, d := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == '\\' && quote == '"' {
+				i++
+			} else if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == '[' || s[i] == '{':
+			d++
+		case s[i] == ']' || s[i] == '}':
+			d--
+		case s[i] == ',' && d == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
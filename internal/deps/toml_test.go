@@ -0,0 +1,70 @@
+package deps
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	content := `# comment
+name = "top"
+
+[package]
+name = "demo" # trailing comment
+"quoted key" = 'literal # not a comment'
+
+[dependencies]
+serde = { version = "1.0", features = ["derive"] }
+list = [
+    "a", # first
+    "b",
+]
+
+[[bin]]
+path = "src/main.rs"
+`
+	want := []tomlEntry{
+		{"", "name", `"top"`},
+		{"package", "name", `"demo"`},
+		{"package", "quoted key", `'literal # not a comment'`},
+		{"dependencies", "serde", `{ version = "1.0", features = ["derive"] }`},
+		{"dependencies", "list", `[ "a", "b", ]`},
+		{"bin", "path", `"src/main.rs"`},
+	}
+	if got := parseTOML(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTOML =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestTOMLValues(t *testing.T) {
+	strs := []struct{ in, want string }{
+		{`"basic \"quoted\""`, `basic "quoted"`},
+		{`'C:\path'`, `C:\path`},
+		{`bare`, `bare`},
+	}
+	for _, tt := range strs {
+		if got := tomlString(tt.in); got != tt.want {
+			t.Errorf("tomlString(%s) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	if got, want := tomlArray(`["requests>=2", 'click', "a,b"]`), []string{"requests>=2", "click", "a,b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tomlArray = %q, want %q", got, want)
+	}
+	if got := tomlArray(`"not an array"`); got != nil {
+		t.Errorf("tomlArray of a string = %q, want nil", got)
+	}
+
+	versions := []struct{ in, want string }{
+		{`"1.0"`, "1.0"},
+		{`{ version = "0.4", optional = true }`, "0.4"},
+		{`{ git = "https://example.com/x.git", branch = "main" }`, "git:https://example.com/x.git"},
+		{`{ path = "../local" }`, "path:../local"},
+		{`{ workspace = true }`, ""},
+	}
+	for _, tt := range versions {
+		if got := tomlVersion(tt.in); got != tt.want {
+			t.Errorf("tomlVersion(%s) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
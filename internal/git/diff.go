@@ -0,0 +1,53 @@
+// Package git provides functionality to retrieve Git repository information.
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TopLevel returns the root of the working tree containing dir.
+func TopLevel(dir string) (string, error) {
+	top, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("not a git repo")
+	}
+	return top, nil
+}
+
+// diffArgs builds the common arguments comparing base with the working tree,
+// or with the index when staged is set.
+func diffArgs(base string, staged bool, extra ...string) []string {
+	args := []string{"diff", "--no-color", "--no-ext-diff"}
+	if staged {
+		args = append(args, "--cached")
+	}
+	args = append(args, extra...)
+	if base != "" {
+		args = append(args, base)
+	}
+	return append(args, "--")
+}
+
+// ChangedFiles lists the paths (relative to the repository root) that differ
+// between base and the working tree or index. Deleted files are omitted.
+func ChangedFiles(root, base string, staged bool) ([]string, error) {
+	out, err := run(root, diffArgs(base, staged, "--name-only", "--diff-filter=d")...)
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s: %w", base, err)
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// Diff returns the unified diff between base and the working tree or index,
+// limited to paths when any are given.
+func Diff(root, base string, staged bool, paths ...string) (string, error) {
+	out, err := run(root, append(diffArgs(base, staged), paths...)...)
+	if err != nil {
+		return "", fmt.Errorf("git diff %s: %w", base, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,106 @@
+// Package git provides functionality to retrieve Git repository information.
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/models"
+)
+
+// FileHistory collects per-file history for files (paths relative to root)
+// in a single pass over `git log --name-only`: the last commit touching each
+// file, and the number of commits and distinct authors committed since the
+// given time (the whole history when since is zero). Files without history
+// are absent from the result.
+func FileHistory(root string, since time.Time, files []string) (map[string]*models.FileHistory, error) {
+	wanted := make(map[string]bool, len(files))
+	for _, f := range files {
+		wanted[f] = true
+	}
+	// -z keeps paths unquoted; every header and path ends in a NUL.
+	cmd := exec.Command("git", "log", "--no-color", "--no-renames", "--relative", "--name-only", "-z",
+		"--format="+recordSep+strings.Join([]string{"%H", "%aN <%aE>", "%aI", "%cI"}, fieldSep), "--", ".")
+	cmd.Dir = root
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	history := map[string]*models.FileHistory{}
+	authors := map[string]map[string]bool{}
+	var hash, author, date string
+	inWindow := true
+	afterHeader := false
+
+	sc := bufio.NewScanner(stdout)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	sc.Split(splitNUL)
+	for sc.Scan() {
+		field := sc.Text()
+		if afterHeader {
+			// The header is followed by a newline before the first path.
+			field = strings.TrimPrefix(field, "\n")
+			afterHeader = false
+		}
+		if header, ok := strings.CutPrefix(field, recordSep); ok {
+			fields := strings.Split(header, fieldSep)
+			if len(fields) < 4 {
+				continue
+			}
+			hash, author, date = fields[0], fields[1], fields[2]
+			// git log lists commits by commit date, not author date, and
+			// may still reach commits in the window after older ones.
+			committed, _ := time.Parse(time.RFC3339, fields[3])
+			inWindow = since.IsZero() || !committed.Before(since)
+			afterHeader = true
+			continue
+		}
+		if field == "" || !wanted[field] {
+			continue
+		}
+		h := history[field]
+		if h == nil {
+			h = &models.FileHistory{LastCommit: hash, LastAuthor: author, LastDate: date}
+			history[field] = h
+			authors[field] = map[string]bool{}
+		}
+		if inWindow {
+			h.Commits++
+			if !authors[field][author] {
+				authors[field][author] = true
+				h.Authors++
+			}
+		}
+	}
+	scanErr := sc.Err()
+	if scanErr != nil {
+		_ = cmd.Process.Kill()
+	}
+	waitErr := cmd.Wait()
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	if waitErr != nil {
+		return nil, fmt.Errorf("git log: %w", waitErr)
+	}
+	return history, nil
+}
+
+// splitNUL is a bufio.SplitFunc returning NUL-terminated fields.
+func splitNUL(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
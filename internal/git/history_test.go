@@ -0,0 +1,111 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestRepo creates an empty repository, skipping the test when the git
+// binary is unavailable.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	gitCmd(t, dir, nil, "init", "-q", "-b", "main")
+	return dir
+}
+
+// gitCmd runs git in dir with a fixed identity and the extra environment.
+func gitCmd(t *testing.T, dir string, env []string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "HOME="+dir), env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commitFiles writes files (name to content) and commits them with the
+// given author and committer dates.
+func commitFiles(t *testing.T, dir, author string, authorDate, commitDate time.Time, files map[string]string) string {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitCmd(t, dir, nil, "add", "-A")
+	gitCmd(t, dir, []string{
+		"GIT_AUTHOR_NAME=" + author, "GIT_AUTHOR_EMAIL=" + strings.ToLower(author) + "@example.com",
+		"GIT_AUTHOR_DATE=" + authorDate.Format(time.RFC3339),
+		"GIT_COMMITTER_DATE=" + commitDate.Format(time.RFC3339),
+	}, "commit", "-q", "-m", "change "+author)
+	return gitCmd(t, dir, nil, "rev-parse", "HEAD")
+}
+
+func TestFileHistory(t *testing.T) {
+	dir := newTestRepo(t)
+	since := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	commitFiles(t, dir, "Ann", since.Add(-30*day), since.Add(-30*day), map[string]string{
+		"old.go": "1", "naïve file.txt": "1", "tab\tname.txt": "1",
+	})
+	commitFiles(t, dir, "Ann", since.Add(1*day), since.Add(1*day), map[string]string{
+		"naïve file.txt": "2",
+	})
+	// Authored before the window but committed inside it, as after a rebase.
+	commitFiles(t, dir, "Bob", since.Add(-10*day), since.Add(2*day), map[string]string{
+		"naïve file.txt": "3", "tab\tname.txt": "2",
+	})
+	head := commitFiles(t, dir, "Cid", since.Add(3*day), since.Add(3*day), map[string]string{
+		"old.go": "2", "naïve file.txt": "4", "tab\tname.txt": "3",
+	})
+
+	files := []string{"old.go", "naïve file.txt", "tab\tname.txt", "untracked.go"}
+	tests := []struct {
+		name  string
+		since time.Time
+		want  map[string][2]int // commits, authors
+	}{
+		{"whole history", time.Time{}, map[string][2]int{"old.go": {2, 2}, "naïve file.txt": {4, 3}, "tab\tname.txt": {3, 3}}},
+		{"window", since, map[string][2]int{"old.go": {1, 1}, "naïve file.txt": {3, 3}, "tab\tname.txt": {2, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history, err := FileHistory(dir, tt.since, files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(history) != len(tt.want) {
+				t.Errorf("history for %d files, want %d", len(history), len(tt.want))
+			}
+			for f, want := range tt.want {
+				h := history[f]
+				if h == nil {
+					t.Errorf("%q: no history", f)
+					continue
+				}
+				if got := [2]int{h.Commits, h.Authors}; got != want {
+					t.Errorf("%q: commits, authors = %v, want %v", f, got, want)
+				}
+			}
+			if h := history["naïve file.txt"]; h != nil && (h.LastCommit != head || !strings.HasPrefix(h.LastAuthor, "Cid ")) {
+				t.Errorf("last commit = %s by %s, want %s by Cid", h.LastCommit, h.LastAuthor, head)
+			}
+		})
+	}
+}
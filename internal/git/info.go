@@ -3,52 +3,362 @@ package git
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/AndersonTsaiTW/RepoGo/internal/models"
 )
 
+// run executes git with args in dir and returns its trimmed standard output.
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// rfc2822 is the date layout of git's --date=rfc.
+const rfc2822 = "Mon, 2 Jan 2006 15:04:05 -0700"
+
 // GetInfo retrieves Git repository information from the specified root directory.
 // Returns nil if the directory is not a Git repository.
+//
+// The repository files are read directly, so the git binary is only needed
+// for repositories the reader does not support (such as SHA-256 object
+// formats), where GetInfo falls back to the CLI, and for ahead/behind counts
+// over diverging histories longer than the reader walks.
 func GetInfo(root string) (*models.GitInfo, error) {
-	// Commands will fail if root is not a git repo
-	run := func(args ...string) (string, error) {
-		cmd := exec.Command("git", args...)
-		cmd.Dir = root
-		out, err := cmd.Output()
-		if err != nil {
-			return "", err
+	if info, err := nativeInfo(root); err == nil {
+		return info, nil
+	}
+	return cliInfo(root)
+}
+
+func nativeInfo(root string) (*models.GitInfo, error) {
+	r, err := openRepository(root)
+	if err != nil {
+		return nil, err
+	}
+	hash, branch, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+	c, err := r.readCommit(hash)
+	if err != nil {
+		return nil, err
+	}
+	info := &models.GitInfo{
+		Commit:   hash,
+		Branch:   branch,
+		Detached: branch == "",
+		Author:   c.author,
+		Date:     c.date.Format(rfc2822),
+		Tags:     r.tagsAt(hash),
+	}
+	cfg := r.config()
+	if branch != "" {
+		remote := cfg.get("branch." + branch + ".remote")
+		info.Upstream = upstreamName(cfg, branch)
+		if info.Upstream != "" {
+			ref := "refs/remotes/" + info.Upstream
+			if remote == "." {
+				ref = "refs/heads/" + info.Upstream
+			}
+			ok := false
+			if up, err := r.resolveRef(ref); err == nil {
+				info.Ahead, info.Behind, ok = r.aheadBehind(hash, up)
+			}
+			if !ok {
+				info.Ahead, info.Behind, _ = aheadBehind(root)
+			}
+		}
+	}
+	info.Remotes = configRemotes(cfg)
+	info.Submodules = withInfo(r.workTree, r.submodules())
+	return info, nil
+}
+
+// upstreamName derives the remote-tracking branch of branch from its
+// branch.<name>.remote and branch.<name>.merge settings.
+func upstreamName(cfg gitConfig, branch string) string {
+	remote := cfg.get("branch." + branch + ".remote")
+	merge := strings.TrimPrefix(cfg.get("branch."+branch+".merge"), "refs/heads/")
+	switch {
+	case remote == "" || merge == "":
+		return ""
+	case remote == ".":
+		return merge
+	}
+	return remote + "/" + merge
+}
+
+// configRemotes lists the URL of each remote configured in cfg.
+func configRemotes(cfg gitConfig) []models.Remote {
+	var list []models.Remote
+	for key, values := range cfg {
+		if name, ok := strings.CutPrefix(key, "remote."); ok && strings.HasSuffix(name, ".url") && len(values) > 0 {
+			list = append(list, models.Remote{Name: strings.TrimSuffix(name, ".url"), URL: StripCredentials(values[0])})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// submodules lists the submodules declared in .gitmodules with the commit
+// the index pins them to, comparing it with each submodule's checkout.
+func (r *repository) submodules() []models.Submodule {
+	cfg := readConfig(filepath.Join(r.workTree, ".gitmodules"))
+	var paths []string
+	for key, values := range cfg {
+		if strings.HasPrefix(key, "submodule.") && strings.HasSuffix(key, ".path") && len(values) > 0 {
+			paths = append(paths, values[len(values)-1])
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	sort.Strings(paths)
+	links, err := r.indexGitlinks()
+	if err != nil {
+		// Fall back to the commits pinned by HEAD.
+		links = map[string]string{}
+		if hash, _, err := r.head(); err == nil {
+			if c, err := r.readCommit(hash); err == nil {
+				for _, p := range paths {
+					if mode, pinned, err := r.treeEntry(c.tree, p); err == nil && mode == "160000" {
+						links[p] = pinned
+					}
+				}
+			}
+		}
+	}
+	var list []models.Submodule
+	for _, p := range paths {
+		pinned, ok := links[p]
+		if !ok {
+			continue
 		}
-		return strings.TrimSpace(string(out)), nil
+		sm := models.Submodule{Path: p, Commit: pinned}
+		if sub, err := openWorkTree(filepath.Join(r.workTree, filepath.FromSlash(p))); err != nil {
+			sm.Status = "uninitialized"
+		} else if checkedOut, _, err := sub.head(); err != nil || checkedOut != pinned {
+			sm.Status = "modified"
+		}
+		list = append(list, sm)
 	}
+	return list
+}
 
+// cliInfo gathers the repository information with the git binary.
+func cliInfo(root string) (*models.GitInfo, error) {
 	// First check if .git or HEAD is readable
 	if _, err := os.Stat(filepath.Join(root, ".git")); err != nil {
 		// Could be in subdirectory, try git rev-parse
-		if _, err2 := run("rev-parse", "--git-dir"); err2 != nil {
+		if _, err2 := run(root, "rev-parse", "--git-dir"); err2 != nil {
 			return nil, fmt.Errorf("not a git repo")
 		}
 	}
 
-	commit, err := run("rev-parse", "HEAD")
+	// Commands will fail if root is not a git repo
+	commit, err := run(root, "rev-parse", "HEAD")
 	if err != nil {
 		return nil, err
 	}
-	branch, _ := run("rev-parse", "--abbrev-ref", "HEAD")
-	authorName, _ := run("log", "-1", "--pretty=%an")
-	authorEmail, _ := run("log", "-1", "--pretty=%ae")
-	dateRaw, _ := run("log", "-1", "--pretty=%ad", "--date=rfc")
+	authorName, _ := run(root, "log", "-1", "--pretty=%an")
+	authorEmail, _ := run(root, "log", "-1", "--pretty=%ae")
+	dateRaw, _ := run(root, "log", "-1", "--pretty=%ad", "--date=rfc")
 	if dateRaw == "" {
 		dateRaw = time.Now().Format(time.RFC1123Z)
 	}
-	return &models.GitInfo{
+	info := &models.GitInfo{
 		Commit: commit,
-		Branch: branch,
 		Author: fmt.Sprintf("%s <%s>", authorName, authorEmail),
 		Date:   dateRaw,
-	}, nil
+	}
+	// symbolic-ref fails on a detached HEAD, where rev-parse --abbrev-ref
+	// would report the literal "HEAD".
+	if branch, err := run(root, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		info.Branch = branch
+		if info.Upstream, err = run(root, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); err == nil {
+			info.Ahead, info.Behind, _ = aheadBehind(root)
+		}
+	} else {
+		info.Detached = true
+	}
+	if tags, _ := run(root, "tag", "--points-at", "HEAD"); tags != "" {
+		info.Tags = strings.Split(tags, "\n")
+	}
+	info.Remotes = remotes(root)
+	if top, err := TopLevel(root); err == nil {
+		info.Submodules = withInfo(top, submodules(top))
+	}
+	return info, nil
+}
+
+// aheadBehind counts the commits HEAD is ahead of and behind its upstream
+// with git; ok is false when git is unavailable.
+func aheadBehind(root string) (ahead, behind int, ok bool) {
+	counts, err := run(root, "rev-list", "--left-right", "--count", "HEAD...@{upstream}")
+	if err != nil {
+		return 0, 0, false
+	}
+	fields := strings.Fields(counts)
+	if len(fields) != 2 {
+		return 0, 0, false
+	}
+	ahead, _ = strconv.Atoi(fields[0])
+	behind, _ = strconv.Atoi(fields[1])
+	return ahead, behind, true
+}
+
+// remotes lists the fetch URL of each configured remote using the CLI.
+func remotes(root string) []models.Remote {
+	out, err := run(root, "remote", "-v")
+	if err != nil || out == "" {
+		return nil
+	}
+	var list []models.Remote
+	for _, line := range strings.Split(out, "\n") {
+		// "origin<TAB>https://github.com/owner/repo.git (fetch)"
+		name, rest, ok := strings.Cut(line, "\t")
+		if !ok || !strings.HasSuffix(rest, " (fetch)") {
+			continue
+		}
+		list = append(list, models.Remote{Name: name, URL: StripCredentials(strings.TrimSuffix(rest, " (fetch)"))})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// StripCredentials removes secrets from a remote URL: the whole user info
+// of HTTP(S) URLs, which commonly carries access tokens, and the password
+// of other URLs. SSH shorthand such as git@host:owner/repo is left as is.
+// URLs that do not parse lose everything between "://" and the host.
+func StripCredentials(raw string) string {
+	scheme, rest, ok := strings.Cut(raw, "://")
+	if !ok {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		// Cut at the last "@" before the path, or anywhere if the
+		// credentials themselves hold a slash.
+		authority, _, _ := strings.Cut(rest, "/")
+		at := strings.LastIndexByte(authority, '@')
+		if at < 0 {
+			at = strings.LastIndexByte(rest, '@')
+		}
+		if at < 0 {
+			return raw
+		}
+		return scheme + "://" + rest[at+1:]
+	}
+	if u.User == nil {
+		return raw
+	}
+	switch {
+	case u.Scheme == "http" || u.Scheme == "https":
+		u.User = nil
+	default:
+		u.User = url.User(u.User.Username())
+	}
+	return u.String()
+}
+
+// submodules lists the submodules registered in the working tree top
+// using the CLI.
+func submodules(top string) []models.Submodule {
+	if _, err := os.Stat(filepath.Join(top, ".gitmodules")); err != nil {
+		return nil
+	}
+	// Not run(): trimming would drop the status flag of the first line.
+	cmd := exec.Command("git", "submodule", "status")
+	cmd.Dir = top
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	var list []models.Submodule
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		// "<flag><sha> <path> (<describe>)", where flag is ' ', '-', '+' or 'U'.
+		if len(line) < 2 {
+			continue
+		}
+		commit, path, ok := strings.Cut(line[1:], " ")
+		if !ok {
+			continue
+		}
+		if i := strings.LastIndex(path, " ("); i >= 0 && strings.HasSuffix(path, ")") {
+			path = path[:i]
+		}
+		sm := models.Submodule{Commit: commit, Path: path}
+		switch line[0] {
+		case '-':
+			sm.Status = "uninitialized"
+		case '+':
+			// The listed commit is the checked-out one; report the pinned one.
+			sm.Status = "modified"
+			if staged, err := run(top, "ls-files", "--stage", "--", path); err == nil {
+				if fields := strings.Fields(staged); len(fields) >= 2 {
+					sm.Commit = fields[1]
+				}
+			}
+		case 'U':
+			sm.Status = "conflict"
+		}
+		list = append(list, sm)
+	}
+	return list
+}
+
+// withInfo attaches the repository information of each initialized
+// submodule of the working tree top.
+func withInfo(top string, list []models.Submodule) []models.Submodule {
+	for i, sm := range list {
+		if sm.Status == "uninitialized" {
+			continue
+		}
+		if gi, err := GetInfo(filepath.Join(top, filepath.FromSlash(sm.Path))); err == nil {
+			list[i].Git = gi
+		}
+	}
+	return list
+}
+
+// Submodules lists the submodules of the repository containing dir that
+// lie below dir, with paths relative to dir. Their Git field is not set.
+func Submodules(dir string) ([]models.Submodule, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var top string
+	var list []models.Submodule
+	if r, err := openRepository(dir); err == nil {
+		top, list = r.workTree, r.submodules()
+	} else {
+		if top, err = TopLevel(dir); err != nil {
+			return nil, err
+		}
+		list = submodules(top)
+	}
+	var below []models.Submodule
+	for _, sm := range list {
+		rel, err := filepath.Rel(dir, filepath.Join(top, filepath.FromSlash(sm.Path)))
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		sm.Path = filepath.ToSlash(rel)
+		below = append(below, sm)
+	}
+	return below, nil
 }
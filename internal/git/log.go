@@ -0,0 +1,69 @@
+// Package git provides functionality to retrieve Git repository information.
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/models"
+)
+
+// Record and field separators used in the log format.
+const (
+	recordSep = "\x1e"
+	fieldSep  = "\x1f"
+)
+
+// Log returns the last n commits reachable from HEAD, newest first. With
+// stats set each commit lists the lines added and deleted per file. When
+// paths are given only commits touching them are reported; they are
+// interpreted relative to root.
+func Log(root string, n int, stats bool, paths ...string) ([]models.Commit, error) {
+	args := []string{"log", "-n", strconv.Itoa(n), "--no-color",
+		"--format=" + recordSep + strings.Join([]string{"%H", "%an <%ae>", "%aI", "%s"}, fieldSep)}
+	if stats {
+		args = append(args, "--numstat")
+	}
+	args = append(args, "--")
+	for _, p := range paths {
+		args = append(args, ":(literal)"+p)
+	}
+	out, err := run(root, args...)
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	return parseLog(out), nil
+}
+
+func parseLog(out string) []models.Commit {
+	var commits []models.Commit
+	for _, record := range strings.Split(out, recordSep) {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+		header, rest, _ := strings.Cut(record, "\n")
+		fields := strings.Split(header, fieldSep)
+		if len(fields) < 4 {
+			continue
+		}
+		c := models.Commit{Hash: fields[0], Author: fields[1], Date: fields[2], Subject: fields[3]}
+		for _, line := range strings.Split(rest, "\n") {
+			// --numstat: "added<TAB>deleted<TAB>path", with "-" for binary files.
+			parts := strings.SplitN(line, "\t", 3)
+			if len(parts) != 3 {
+				continue
+			}
+			fc := models.FileChange{Path: parts[2]}
+			if parts[0] == "-" {
+				fc.Binary = true
+			} else {
+				fc.Added, _ = strconv.Atoi(parts[0])
+				fc.Deleted, _ = strconv.Atoi(parts[1])
+			}
+			c.Files = append(c.Files, fc)
+		}
+		commits = append(commits, c)
+	}
+	return commits
+}
@@ -0,0 +1,605 @@
+// Package git provides functionality to retrieve Git repository information.
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"container/heap"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Object types as encoded in packfiles.
+const (
+	objCommit   = 1
+	objTree     = 2
+	objBlob     = 3
+	objTag      = 4
+	objOfsDelta = 6
+	objRefDelta = 7
+)
+
+var objectTypes = map[int]string{objCommit: "commit", objTree: "tree", objBlob: "blob", objTag: "tag"}
+
+// maxDeltaChain bounds delta resolution so a corrupt pack cannot loop.
+const maxDeltaChain = 1000
+
+var errCorruptDelta = errors.New("corrupt delta")
+
+// objectDirs returns the object directory and any alternates.
+func (r *repository) objectDirs() []string {
+	objects := filepath.Join(r.commonDir, "objects")
+	dirs := []string{objects}
+	b, err := os.ReadFile(filepath.Join(objects, "info", "alternates"))
+	if err != nil {
+		return dirs
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if line = strings.TrimSpace(line); line != "" && line[0] != '#' {
+			dirs = append(dirs, resolveFrom(objects, line))
+		}
+	}
+	return dirs
+}
+
+// readObject returns the type ("commit", "tree", "blob" or "tag") and
+// content of the object with the given hex hash, from a loose object or a
+// packfile.
+func (r *repository) readObject(hash string) (string, []byte, error) {
+	if !isHash(hash) {
+		return "", nil, fmt.Errorf("invalid object name %q", hash)
+	}
+	for _, dir := range r.objectDirs() {
+		typ, data, err := readLoose(filepath.Join(dir, hash[:2], hash[2:]))
+		if err == nil {
+			return typ, data, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", nil, fmt.Errorf("object %s: %w", hash, err)
+		}
+	}
+	packs, err := r.packIndexes()
+	if err != nil {
+		return "", nil, err
+	}
+	raw, _ := hex.DecodeString(hash)
+	for _, p := range packs {
+		if offset, ok := p.find(raw); ok {
+			typ, data, err := r.readPacked(p.pack, offset)
+			if err != nil {
+				return "", nil, fmt.Errorf("object %s: %w", hash, err)
+			}
+			return typ, data, nil
+		}
+	}
+	return "", nil, fmt.Errorf("object %s not found", hash)
+}
+
+// readLoose inflates a loose object: "<type> <size>\x00<content>".
+func readLoose(path string) (string, []byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return "", nil, err
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return "", nil, err
+	}
+	hdr, content, ok := bytes.Cut(data, []byte{0})
+	if !ok {
+		return "", nil, errors.New("malformed loose object")
+	}
+	typ, _, _ := strings.Cut(string(hdr), " ")
+	return typ, content, nil
+}
+
+// packIndex is a version 2 pack index (.idx), mapping object names to
+// offsets in the corresponding .pack file.
+type packIndex struct {
+	pack    string
+	fanout  [256]uint32
+	names   []byte // sorted 20-byte object names
+	offsets []byte // 4-byte offsets, or indexes into large with the high bit set
+	large   []byte // 8-byte offsets for packs over 2 GiB
+}
+
+// packIndexes loads every pack index once.
+func (r *repository) packIndexes() ([]*packIndex, error) {
+	r.packsOnce.Do(func() {
+		for _, dir := range r.objectDirs() {
+			paths, _ := filepath.Glob(filepath.Join(dir, "pack", "*.idx"))
+			for _, p := range paths {
+				idx, err := readPackIndex(p)
+				if err != nil {
+					r.packsErr = err
+					return
+				}
+				r.packs = append(r.packs, idx)
+			}
+		}
+	})
+	return r.packs, r.packsErr
+}
+
+func readPackIndex(path string) (*packIndex, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	const fanoutEnd = 8 + 256*4
+	if len(b) < fanoutEnd || !bytes.Equal(b[:4], []byte("\xfftOc")) || binary.BigEndian.Uint32(b[4:8]) != 2 {
+		return nil, fmt.Errorf("%s: unsupported pack index version", path)
+	}
+	idx := &packIndex{pack: strings.TrimSuffix(path, ".idx") + ".pack"}
+	for i := range idx.fanout {
+		idx.fanout[i] = binary.BigEndian.Uint32(b[8+4*i:])
+	}
+	n := int(idx.fanout[255])
+	names := fanoutEnd
+	crcs := names + 20*n
+	offsets := crcs + 4*n
+	large := offsets + 4*n
+	if len(b) < large {
+		return nil, fmt.Errorf("%s: truncated pack index", path)
+	}
+	idx.names = b[names:crcs]
+	idx.offsets = b[offsets:large]
+	idx.large = b[large:]
+	return idx, nil
+}
+
+// find returns the pack offset of the object with the given binary name.
+func (p *packIndex) find(name []byte) (int64, bool) {
+	lo := 0
+	if name[0] > 0 {
+		lo = int(p.fanout[name[0]-1])
+	}
+	hi := int(p.fanout[name[0]])
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(p.names[(lo+i)*20:(lo+i+1)*20], name) >= 0
+	})
+	if i >= hi || !bytes.Equal(p.names[i*20:(i+1)*20], name) {
+		return 0, false
+	}
+	offset := binary.BigEndian.Uint32(p.offsets[i*4:])
+	if offset&0x80000000 == 0 {
+		return int64(offset), true
+	}
+	j := int(offset&0x7fffffff) * 8
+	if j+8 > len(p.large) {
+		return 0, false
+	}
+	return int64(binary.BigEndian.Uint64(p.large[j:])), true
+}
+
+// readPacked reads the object at offset in the pack at path, resolving
+// deltas against their base objects.
+func (r *repository) readPacked(path string, offset int64) (string, []byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	return r.unpack(f, offset, 0)
+}
+
+func (r *repository) unpack(f *os.File, offset int64, depth int) (string, []byte, error) {
+	if depth > maxDeltaChain {
+		return "", nil, errors.New("delta chain too long")
+	}
+	br := bufio.NewReader(io.NewSectionReader(f, offset, 1<<62))
+	// Header: 3-bit type and a size continued in 7-bit groups.
+	c, err := br.ReadByte()
+	if err != nil {
+		return "", nil, err
+	}
+	typ := int(c>>4) & 7
+	size := int64(c & 0x0f)
+	for shift := 4; c&0x80 != 0; shift += 7 {
+		if c, err = br.ReadByte(); err != nil {
+			return "", nil, err
+		}
+		size |= int64(c&0x7f) << shift
+	}
+
+	switch typ {
+	case objCommit, objTree, objBlob, objTag:
+		data, err := inflate(br, size)
+		return objectTypes[typ], data, err
+	case objOfsDelta:
+		// The base lies a negative distance back in the same pack.
+		c, err := br.ReadByte()
+		if err != nil {
+			return "", nil, err
+		}
+		distance := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = br.ReadByte(); err != nil {
+				return "", nil, err
+			}
+			distance = (distance+1)<<7 | int64(c&0x7f)
+		}
+		delta, err := inflate(br, size)
+		if err != nil {
+			return "", nil, err
+		}
+		baseType, base, err := r.unpack(f, offset-distance, depth+1)
+		if err != nil {
+			return "", nil, err
+		}
+		data, err := applyDelta(base, delta)
+		return baseType, data, err
+	case objRefDelta:
+		var name [20]byte
+		if _, err := io.ReadFull(br, name[:]); err != nil {
+			return "", nil, err
+		}
+		delta, err := inflate(br, size)
+		if err != nil {
+			return "", nil, err
+		}
+		baseType, base, err := r.readObject(hex.EncodeToString(name[:]))
+		if err != nil {
+			return "", nil, err
+		}
+		data, err := applyDelta(base, delta)
+		return baseType, data, err
+	}
+	return "", nil, fmt.Errorf("unknown pack object type %d", typ)
+}
+
+func inflate(r io.Reader, size int64) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data := make([]byte, size)
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// applyDelta rebuilds an object from its base and a delta: the base and
+// result sizes followed by copy-from-base and insert instructions.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	baseSize, delta := deltaSize(delta)
+	if baseSize != len(base) {
+		return nil, errCorruptDelta
+	}
+	size, delta := deltaSize(delta)
+	out := make([]byte, 0, size)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+		switch {
+		case op&0x80 != 0:
+			var offset, n int
+			for i := 0; i < 7; i++ {
+				if op&(1<<i) == 0 {
+					continue
+				}
+				if len(delta) == 0 {
+					return nil, errCorruptDelta
+				}
+				if i < 4 {
+					offset |= int(delta[0]) << (8 * i)
+				} else {
+					n |= int(delta[0]) << (8 * (i - 4))
+				}
+				delta = delta[1:]
+			}
+			if n == 0 {
+				n = 0x10000
+			}
+			if offset+n > len(base) {
+				return nil, errCorruptDelta
+			}
+			out = append(out, base[offset:offset+n]...)
+		case op != 0:
+			if int(op) > len(delta) {
+				return nil, errCorruptDelta
+			}
+			out = append(out, delta[:op]...)
+			delta = delta[op:]
+		default:
+			return nil, errCorruptDelta
+		}
+	}
+	if len(out) != size {
+		return nil, errCorruptDelta
+	}
+	return out, nil
+}
+
+// deltaSize decodes a little-endian base-128 size from the start of b.
+func deltaSize(b []byte) (int, []byte) {
+	n := 0
+	for i, c := range b {
+		n |= int(c&0x7f) << (7 * i)
+		if c&0x80 == 0 {
+			return n, b[i+1:]
+		}
+	}
+	return n, nil
+}
+
+// commit holds the parts of a commit object used for repository info.
+type commit struct {
+	tree      string
+	parents   []string
+	author    string // "Name <email>"
+	date      time.Time
+	committed time.Time
+}
+
+func (r *repository) readCommit(hash string) (*commit, error) {
+	typ, data, err := r.readObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	if typ != "commit" {
+		return nil, fmt.Errorf("object %s is a %s, not a commit", hash, typ)
+	}
+	c := &commit{}
+	hdr, _, _ := bytes.Cut(data, []byte("\n\n"))
+	for _, line := range strings.Split(string(hdr), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "tree":
+			c.tree = value
+		case "parent":
+			c.parents = append(c.parents, value)
+		case "author":
+			c.author, c.date = parseSignature(value)
+		case "committer":
+			_, c.committed = parseSignature(value)
+		}
+	}
+	return c, nil
+}
+
+// maxWalk bounds the commits read by aheadBehind.
+const maxWalk = 100000
+
+// aheadBehind counts the commits reachable from a but not b, and from b but
+// not a. Commits are visited newest first, by commit date, marking which
+// side reaches them; a side reaching a commit that was already walked is
+// passed on to its ancestors at once, so clock skew cannot leave them
+// miscounted. As in git, the walk stops a few commits after every queued
+// commit is reached from both sides, so only the diverging history is read.
+// ok is false if a history is unreadable or more than maxWalk commits are
+// read.
+func (r *repository) aheadBehind(a, b string) (ahead, behind int, ok bool) {
+	const fromA, fromB, fromBoth = 1, 2, 3
+	const slop = 5
+	if a == b {
+		return 0, 0, true
+	}
+	flags := map[string]int{}
+	commits := map[string]*commit{}
+	walked := map[string]bool{}
+	var queue walkQueue
+	pending := 0 // queued commits not yet reached from both sides
+	var mark func(hash string, f int) bool
+	mark = func(hash string, f int) bool {
+		old := flags[hash]
+		if old|f == old {
+			return true
+		}
+		c := commits[hash]
+		if c == nil {
+			if len(commits) >= maxWalk {
+				return false
+			}
+			var err error
+			if c, err = r.readCommit(hash); err != nil {
+				return false
+			}
+			commits[hash] = c
+			heap.Push(&queue, walkEntry{hash: hash, commit: c})
+			if f != fromBoth {
+				pending++
+			}
+		} else if !walked[hash] && old|f == fromBoth {
+			pending--
+		}
+		flags[hash] = old | f
+		if walked[hash] {
+			for _, p := range c.parents {
+				if !mark(p, f) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	if !mark(a, fromA) || !mark(b, fromB) {
+		return 0, 0, false
+	}
+	for left := slop; queue.Len() > 0; {
+		e := heap.Pop(&queue).(walkEntry)
+		walked[e.hash] = true
+		f := flags[e.hash]
+		if f != fromBoth {
+			pending--
+		}
+		for _, p := range e.commit.parents {
+			if !mark(p, f) {
+				return 0, 0, false
+			}
+		}
+		if pending > 0 {
+			left = slop
+		} else if left--; left == 0 {
+			break
+		}
+	}
+	for _, f := range flags {
+		switch f {
+		case fromA:
+			ahead++
+		case fromB:
+			behind++
+		}
+	}
+	return ahead, behind, true
+}
+
+// walkEntry is a commit queued by aheadBehind.
+type walkEntry struct {
+	hash   string
+	commit *commit
+}
+
+// walkQueue is a heap of commits, newest commit date first.
+type walkQueue []walkEntry
+
+func (q walkQueue) Len() int           { return len(q) }
+func (q walkQueue) Less(i, j int) bool { return q[i].commit.committed.After(q[j].commit.committed) }
+func (q walkQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *walkQueue) Push(x any)        { *q = append(*q, x.(walkEntry)) }
+func (q *walkQueue) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// header returns the value of the first header line named key in a commit
+// or tag object.
+func header(data []byte, key string) (string, bool) {
+	hdr, _, _ := bytes.Cut(data, []byte("\n\n"))
+	for _, line := range strings.Split(string(hdr), "\n") {
+		if k, v, ok := strings.Cut(line, " "); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// parseSignature splits "Name <email> 1700000000 +0100" into the identity
+// and the time in its recorded zone.
+func parseSignature(s string) (string, time.Time) {
+	end := strings.LastIndexByte(s, '>')
+	if end < 0 {
+		return s, time.Time{}
+	}
+	who := s[:end+1]
+	fields := strings.Fields(s[end+1:])
+	if len(fields) < 2 {
+		return who, time.Time{}
+	}
+	sec, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return who, time.Time{}
+	}
+	tz := fields[1]
+	offset := 0
+	if len(tz) == 5 {
+		h, _ := strconv.Atoi(tz[1:3])
+		m, _ := strconv.Atoi(tz[3:5])
+		offset = h*3600 + m*60
+		if tz[0] == '-' {
+			offset = -offset
+		}
+	}
+	return who, time.Unix(sec, 0).In(time.FixedZone("", offset))
+}
+
+// treeEntry looks up the object at path (slash-separated) below the tree
+// with the given hash and returns its mode and hash.
+func (r *repository) treeEntry(tree, path string) (mode, hash string, err error) {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		typ, data, err := r.readObject(tree)
+		if err != nil {
+			return "", "", err
+		}
+		if typ != "tree" {
+			return "", "", fmt.Errorf("%s: not a directory", strings.Join(parts[:i], "/"))
+		}
+		found := false
+		// Entries are "<mode> <name>\x00<20-byte hash>".
+		for len(data) > 0 {
+			nul := bytes.IndexByte(data, 0)
+			if nul < 0 || nul+21 > len(data) {
+				return "", "", errors.New("malformed tree")
+			}
+			m, name, _ := strings.Cut(string(data[:nul]), " ")
+			h := hex.EncodeToString(data[nul+1 : nul+21])
+			data = data[nul+21:]
+			if name == part {
+				mode, tree, found = m, h, true
+				break
+			}
+		}
+		if !found {
+			return "", "", fmt.Errorf("%s: not found", strings.Join(parts[:i+1], "/"))
+		}
+	}
+	return mode, tree, nil
+}
+
+// indexGitlinks returns the submodule commits (gitlinks) recorded in the
+// index, keyed by path. Only index versions 2 and 3 are supported.
+func (r *repository) indexGitlinks() (map[string]string, error) {
+	b, err := os.ReadFile(filepath.Join(r.gitDir, "index"))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 12 || string(b[:4]) != "DIRC" {
+		return nil, errors.New("malformed index")
+	}
+	version := binary.BigEndian.Uint32(b[4:8])
+	if version != 2 && version != 3 {
+		return nil, fmt.Errorf("unsupported index version %d", version)
+	}
+	n := int(binary.BigEndian.Uint32(b[8:12]))
+	links := map[string]string{}
+	// Entries: 40 bytes of stat data (mode at 24), the 20-byte object name,
+	// 2 bytes of flags, an optional 2 bytes of extended flags, then the
+	// NUL-terminated path padded to a multiple of 8 bytes.
+	pos := 12
+	for range n {
+		start := pos
+		if pos+62 > len(b) {
+			return nil, errors.New("truncated index")
+		}
+		mode := binary.BigEndian.Uint32(b[pos+24:])
+		hash := hex.EncodeToString(b[pos+40 : pos+60])
+		flags := binary.BigEndian.Uint16(b[pos+60:])
+		pos += 62
+		if flags&0x4000 != 0 {
+			pos += 2
+		}
+		nul := bytes.IndexByte(b[pos:], 0)
+		if nul < 0 {
+			return nil, errors.New("truncated index")
+		}
+		name := string(b[pos : pos+nul])
+		pos = start + (pos-start+nul+8)&^7
+		if mode == 0o160000 {
+			links[name] = hash
+		}
+	}
+	return links, nil
+}
@@ -0,0 +1,381 @@
+package git
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// catFile returns the type and raw content of an object as git reports them.
+func catFile(t *testing.T, dir, hash string) (string, []byte) {
+	t.Helper()
+	typ := gitCmd(t, dir, nil, "cat-file", "-t", hash)
+	cmd := exec.Command("git", "cat-file", typ, hash)
+	cmd.Dir = dir
+	data, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git cat-file %s: %v", hash, err)
+	}
+	return typ, data
+}
+
+// historyRepo creates a repository whose files change a little in every
+// commit, so that packing them produces delta chains, and returns it with
+// the names of all its objects.
+func historyRepo(t *testing.T) (string, []string) {
+	t.Helper()
+	dir := newTestRepo(t)
+	var lines []string
+	for i := range 200 {
+		lines = append(lines, fmt.Sprintf("line %d of a file long enough to be stored as a delta", i))
+	}
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 6 {
+		lines[i*30] = fmt.Sprintf("changed in commit %d", i)
+		commitFiles(t, dir, "Ann", date, date, map[string]string{
+			"big.txt":       strings.Join(lines, "\n") + "\n",
+			"dir/small.txt": strconv.Itoa(i),
+		})
+		date = date.Add(time.Hour)
+	}
+	gitCmd(t, dir, nil, "tag", "-a", "-m", "release", "v1.0")
+	var hashes []string
+	for _, line := range strings.Split(gitCmd(t, dir, nil, "rev-list", "--objects", "--all"), "\n") {
+		hash, _, _ := strings.Cut(line, " ")
+		hashes = append(hashes, hash)
+	}
+	hashes = append(hashes, gitCmd(t, dir, nil, "rev-parse", "v1.0")) // the tag object
+	return dir, hashes
+}
+
+// packedTypes counts the objects of each pack type in the repository's packs.
+func packedTypes(t *testing.T, r *repository) map[int]int {
+	t.Helper()
+	packs, err := r.packIndexes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[int]int{}
+	for _, p := range packs {
+		data, err := os.ReadFile(p.pack)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < int(p.fanout[255]); i++ {
+			offset, ok := p.find(p.names[i*20 : (i+1)*20])
+			if !ok {
+				t.Fatalf("object %d of the index not found", i)
+			}
+			counts[int(data[offset]>>4)&7]++
+		}
+	}
+	return counts
+}
+
+func TestReadObject(t *testing.T) {
+	tests := []struct {
+		name   string
+		repack []string // git arguments storing the objects, if any
+		want   int      // pack object type that must occur, if any
+	}{
+		{"loose", nil, 0},
+		{"packed with offset deltas", []string{"repack", "-a", "-d", "-f", "-q"}, objOfsDelta},
+		{"packed with reference deltas", []string{"-c", "repack.useDeltaBaseOffset=false", "repack", "-a", "-d", "-f", "-q"}, objRefDelta},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, hashes := historyRepo(t)
+			if tt.repack != nil {
+				gitCmd(t, dir, nil, tt.repack...)
+			}
+			r, err := openRepository(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want != 0 {
+				if counts := packedTypes(t, r); counts[tt.want] == 0 {
+					t.Fatalf("pack holds no objects of type %d: %v", tt.want, counts)
+				}
+			}
+			for _, hash := range hashes {
+				wantType, wantData := catFile(t, dir, hash)
+				typ, data, err := r.readObject(hash)
+				if err != nil {
+					t.Errorf("readObject(%s): %v", hash, err)
+					continue
+				}
+				if typ != wantType || !bytes.Equal(data, wantData) {
+					t.Errorf("readObject(%s) = %s of %d bytes, want %s of %d bytes", hash, typ, len(data), wantType, len(wantData))
+				}
+			}
+			if _, _, err := r.readObject(strings.Repeat("ab", 20)); err == nil {
+				t.Error("readObject found a missing object")
+			}
+			if _, _, err := r.readObject("HEAD"); err == nil {
+				t.Error("readObject accepted an invalid name")
+			}
+		})
+	}
+}
+
+// buildIndex encodes a version 2 pack index for the given names and offsets.
+func buildIndex(names [][]byte, offsets []int64) []byte {
+	var b bytes.Buffer
+	b.WriteString("\xfftOc")
+	binary.Write(&b, binary.BigEndian, uint32(2))
+	var fanout [256]uint32
+	for _, n := range names {
+		for i := int(n[0]); i < 256; i++ {
+			fanout[i]++
+		}
+	}
+	binary.Write(&b, binary.BigEndian, fanout)
+	for _, n := range names {
+		b.Write(n)
+	}
+	b.Write(make([]byte, 4*len(names))) // CRCs
+	var large []int64
+	for _, off := range offsets {
+		if off < 1<<31 {
+			binary.Write(&b, binary.BigEndian, uint32(off))
+			continue
+		}
+		binary.Write(&b, binary.BigEndian, uint32(0x80000000|len(large)))
+		large = append(large, off)
+	}
+	for _, off := range large {
+		binary.Write(&b, binary.BigEndian, uint64(off))
+	}
+	b.Write(make([]byte, 40)) // pack and index checksums
+	return b.Bytes()
+}
+
+func TestPackIndexFind(t *testing.T) {
+	name := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	names := [][]byte{
+		name("0000000000000000000000000000000000000001"),
+		name("1200000000000000000000000000000000000000"),
+		name("12ffffffffffffffffffffffffffffffffffffff"),
+		name("ab00000000000000000000000000000000000000"),
+		name("ffffffffffffffffffffffffffffffffffffffff"),
+	}
+	offsets := []int64{12, 345, 1 << 33, 6789, 5 << 32}
+	path := filepath.Join(t.TempDir(), "pack-test.idx")
+	if err := os.WriteFile(path, buildIndex(names, offsets), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := readPackIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.pack != strings.TrimSuffix(path, ".idx")+".pack" {
+		t.Errorf("pack = %s", idx.pack)
+	}
+	for i, n := range names {
+		if got, ok := idx.find(n); !ok || got != offsets[i] {
+			t.Errorf("find(%x) = %d, %v; want %d", n, got, ok, offsets[i])
+		}
+	}
+	for _, missing := range []string{
+		"0000000000000000000000000000000000000000",
+		"1200000000000000000000000000000000000001",
+		"5500000000000000000000000000000000000000",
+		"fffffffffffffffffffffffffffffffffffffffe",
+	} {
+		if got, ok := idx.find(name(missing)); ok {
+			t.Errorf("find(%s) = %d, want not found", missing, got)
+		}
+	}
+
+	for _, bad := range [][]byte{
+		[]byte("not an index"),
+		append([]byte("\xfftOc\x00\x00\x00\x03"), make([]byte, 1024)...),
+		buildIndex(names, offsets)[:1100],
+	} {
+		if err := os.WriteFile(path, bad, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := readPackIndex(path); err == nil {
+			t.Errorf("readPackIndex accepted %d malformed bytes", len(bad))
+		}
+	}
+}
+
+func TestApplyDelta(t *testing.T) {
+	base := []byte("The quick brown fox jumps over the lazy dog")
+	tests := []struct {
+		name  string
+		delta []byte
+		want  string // "" for a corrupt delta
+	}{
+		{
+			// copy 4 bytes at 0, insert "red", copy 28 bytes at 15
+			name:  "copy and insert",
+			delta: []byte{43, 35, 0x90, 4, 3, 'r', 'e', 'd', 0x91, 15, 28},
+			want:  "The red fox jumps over the lazy dog",
+		},
+		{
+			name:  "insert only",
+			delta: []byte{43, 2, 2, 'o', 'k'},
+			want:  "ok",
+		},
+		{
+			name:  "wrong base size",
+			delta: []byte{42, 2, 2, 'o', 'k'},
+		},
+		{
+			name:  "copy past the base",
+			delta: []byte{43, 10, 0x91, 40, 10},
+		},
+		{
+			name:  "insert past the delta",
+			delta: []byte{43, 5, 5, 'a'},
+		},
+		{
+			name:  "result size mismatch",
+			delta: []byte{43, 3, 2, 'o', 'k'},
+		},
+		{
+			name:  "reserved opcode",
+			delta: []byte{43, 1, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyDelta(base, tt.delta)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("applyDelta = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil || string(got) != tt.want {
+				t.Errorf("applyDelta = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexGitlinks(t *testing.T) {
+	const pinned = "0123456789abcdef0123456789abcdef01234567"
+	tests := []struct {
+		name    string
+		setup   [][]string // git commands run after adding the gitlinks
+		version uint32
+		wantErr bool
+	}{
+		{"version 2", nil, 2, false},
+		{"version 3 with extended flags", [][]string{{"add", "-N", "new.txt"}}, 3, false},
+		{"version 4", [][]string{{"update-index", "--index-version", "4"}}, 4, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newTestRepo(t)
+			date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			commitFiles(t, dir, "Ann", date, date, map[string]string{"a.txt": "a", "deep/path/b.txt": "b"})
+			gitCmd(t, dir, nil, "update-index", "--add", "--cacheinfo", "160000,"+pinned+",libs/module")
+			gitCmd(t, dir, nil, "update-index", "--add", "--cacheinfo", "160000,"+strings.Repeat("f", 40)+",z-last")
+			if err := os.WriteFile(filepath.Join(dir, "new.txt"), []byte("n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			for _, args := range tt.setup {
+				gitCmd(t, dir, nil, args...)
+			}
+			index, err := os.ReadFile(filepath.Join(dir, ".git", "index"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v := binary.BigEndian.Uint32(index[4:8]); v != tt.version {
+				t.Fatalf("git wrote index version %d, want %d", v, tt.version)
+			}
+
+			r, err := openRepository(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			links, err := r.indexGitlinks()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("indexGitlinks = %v, want an error", links)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]string{"libs/module": pinned, "z-last": strings.Repeat("f", 40)}
+			if fmt.Sprint(links) != fmt.Sprint(want) {
+				t.Errorf("indexGitlinks = %v, want %v", links, want)
+			}
+		})
+	}
+}
+
+func TestAheadBehind(t *testing.T) {
+	dir := newTestRepo(t)
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	n := 0
+	commit := func(offset time.Duration) {
+		n++
+		commitFiles(t, dir, "Ann", date, date.Add(offset), map[string]string{fmt.Sprintf("f%d.txt", n): "x"})
+	}
+	for i := range 5 {
+		commit(time.Duration(i) * time.Hour)
+	}
+	gitCmd(t, dir, nil, "branch", "base")
+	gitCmd(t, dir, nil, "checkout", "-q", "-b", "feature")
+	commit(10 * time.Hour)
+	commit(-48 * time.Hour) // committer clock running behind
+	commit(12 * time.Hour)
+	gitCmd(t, dir, nil, "checkout", "-q", "main")
+	for i := range 4 {
+		commit(time.Duration(20+i) * time.Hour)
+	}
+	gitCmd(t, dir, nil, "checkout", "-q", "-b", "merged", "feature")
+	gitCmd(t, dir, []string{"GIT_COMMITTER_DATE=" + date.Add(30*time.Hour).Format(time.RFC3339)}, "merge", "-q", "--no-edit", "main")
+
+	r, err := openRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pairs := [][2]string{
+		{"main", "main"},
+		{"main", "base"},
+		{"base", "main"},
+		{"feature", "main"},
+		{"merged", "main"},
+		{"merged", "feature"},
+		{"base", "merged"},
+	}
+	for _, p := range pairs {
+		counts := strings.Fields(gitCmd(t, dir, nil, "rev-list", "--left-right", "--count", p[0]+"..."+p[1]))
+		wantAhead, _ := strconv.Atoi(counts[0])
+		wantBehind, _ := strconv.Atoi(counts[1])
+		a, err := r.resolveRef("refs/heads/" + p[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := r.resolveRef("refs/heads/" + p[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		ahead, behind, ok := r.aheadBehind(a, b)
+		if !ok || ahead != wantAhead || behind != wantBehind {
+			t.Errorf("aheadBehind(%s, %s) = %d, %d, %v; want %d, %d", p[0], p[1], ahead, behind, ok, wantAhead, wantBehind)
+		}
+	}
+}
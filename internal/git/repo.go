@@ -0,0 +1,327 @@
+// Package git provides functionality to retrieve Git repository information.
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var errNotRepo = errors.New("not a git repo")
+
+// repository gives direct access to the files of a Git repository without
+// the git binary. gitDir holds HEAD and other per-worktree state; commonDir
+// holds the refs, objects and config shared by all worktrees, and equals
+// gitDir outside linked worktrees.
+type repository struct {
+	workTree  string
+	gitDir    string
+	commonDir string
+
+	packsOnce sync.Once
+	packs     []*packIndex
+	packsErr  error
+}
+
+// openRepository finds the repository containing dir by looking for a .git
+// directory, or a .git file pointing to one, in dir and its parents.
+func openRepository(dir string) (*repository, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		r, err := openWorkTree(dir)
+		if !errors.Is(err, errNotRepo) {
+			return r, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, errNotRepo
+		}
+		dir = parent
+	}
+}
+
+// openWorkTree opens the repository whose working tree is exactly dir.
+func openWorkTree(dir string) (*repository, error) {
+	dotGit := filepath.Join(dir, ".git")
+	fi, err := os.Stat(dotGit)
+	if err != nil {
+		return nil, errNotRepo
+	}
+	gitDir := dotGit
+	if !fi.IsDir() {
+		// Worktrees and submodules use a file containing "gitdir: <path>".
+		if gitDir, err = readGitFile(dotGit); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := os.Stat(filepath.Join(gitDir, "HEAD")); err != nil {
+		return nil, errNotRepo
+	}
+	r := &repository{workTree: dir, gitDir: gitDir, commonDir: gitDir}
+	if b, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		r.commonDir = resolveFrom(gitDir, strings.TrimSpace(string(b)))
+	}
+	if format := r.config().get("extensions.objectformat"); format != "" && format != "sha1" {
+		return nil, fmt.Errorf("unsupported object format %q", format)
+	}
+	return r, nil
+}
+
+func readGitFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	target, ok := strings.CutPrefix(strings.TrimSpace(string(b)), "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("%s: missing gitdir", path)
+	}
+	return resolveFrom(filepath.Dir(path), strings.TrimSpace(target)), nil
+}
+
+// resolveFrom interprets p relative to dir unless it is absolute.
+func resolveFrom(dir, p string) string {
+	p = filepath.FromSlash(p)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	return filepath.Clean(p)
+}
+
+func (r *repository) config() gitConfig {
+	return readConfig(filepath.Join(r.commonDir, "config"))
+}
+
+// head returns the hash HEAD points at and, unless HEAD is detached, the
+// name of the current branch.
+func (r *repository) head() (hash, branch string, err error) {
+	target, err := r.readRef("HEAD")
+	if err != nil {
+		return "", "", err
+	}
+	if ref, ok := strings.CutPrefix(target, "ref: "); ok {
+		branch = strings.TrimPrefix(ref, "refs/heads/")
+	}
+	hash, err = r.resolveRef("HEAD")
+	return hash, branch, err
+}
+
+// resolveRef resolves a ref such as "HEAD" or "refs/heads/main" to a commit
+// hash, following symbolic refs.
+func (r *repository) resolveRef(name string) (string, error) {
+	for range 10 {
+		target, err := r.readRef(name)
+		if err != nil {
+			return "", err
+		}
+		ref, ok := strings.CutPrefix(target, "ref: ")
+		if !ok {
+			if !isHash(target) {
+				return "", fmt.Errorf("ref %s: invalid value %q", name, target)
+			}
+			return target, nil
+		}
+		name = strings.TrimSpace(ref)
+	}
+	return "", fmt.Errorf("ref %s: too many levels of symbolic refs", name)
+}
+
+// readRef returns the raw value of a loose ref, falling back to packed-refs.
+func (r *repository) readRef(name string) (string, error) {
+	for _, dir := range []string{r.gitDir, r.commonDir} {
+		if b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name))); err == nil {
+			return strings.TrimSpace(string(b)), nil
+		}
+	}
+	packed, _ := r.packedRefs()
+	if hash, ok := packed[name]; ok {
+		return hash, nil
+	}
+	return "", fmt.Errorf("ref %s not found", name)
+}
+
+// packedRefs parses the packed-refs file. peeled maps annotated tags to the
+// object they point at when the file records it.
+func (r *repository) packedRefs() (refs, peeled map[string]string) {
+	refs, peeled = map[string]string{}, map[string]string{}
+	b, err := os.ReadFile(filepath.Join(r.commonDir, "packed-refs"))
+	if err != nil {
+		return refs, peeled
+	}
+	last := ""
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line[0] == '#':
+		case line[0] == '^':
+			if last != "" {
+				peeled[last] = line[1:]
+			}
+		default:
+			if hash, name, ok := strings.Cut(line, " "); ok {
+				refs[name] = hash
+				last = name
+			}
+		}
+	}
+	return refs, peeled
+}
+
+// listRefs returns the refs whose names start with prefix, loose refs
+// taking precedence over packed ones.
+func (r *repository) listRefs(prefix string) map[string]string {
+	packed, _ := r.packedRefs()
+	refs := map[string]string{}
+	for name, hash := range packed {
+		if strings.HasPrefix(name, prefix) {
+			refs[name] = hash
+		}
+	}
+	_ = filepath.WalkDir(filepath.Join(r.commonDir, filepath.FromSlash(prefix)), func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		if rel, err := filepath.Rel(r.commonDir, p); err == nil {
+			refs[filepath.ToSlash(rel)] = strings.TrimSpace(string(b))
+		}
+		return nil
+	})
+	return refs
+}
+
+// tagsAt returns the names of the tags pointing at hash, peeling annotated
+// tags to the object they tag.
+func (r *repository) tagsAt(hash string) []string {
+	_, peeled := r.packedRefs()
+	var tags []string
+	for name, target := range r.listRefs("refs/tags/") {
+		if p, ok := peeled[name]; ok {
+			target = p
+		}
+		if target != hash {
+			target = r.peel(target)
+		}
+		if target == hash {
+			tags = append(tags, strings.TrimPrefix(name, "refs/tags/"))
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// peel follows tag objects to the object they ultimately point at.
+func (r *repository) peel(hash string) string {
+	for range 10 {
+		typ, data, err := r.readObject(hash)
+		if err != nil || typ != "tag" {
+			return hash
+		}
+		target, ok := header(data, "object")
+		if !ok {
+			return hash
+		}
+		hash = target
+	}
+	return hash
+}
+
+func isHash(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// gitConfig holds the values of a git config file by lower-cased
+// "section.subsection.key" name; subsection names keep their case.
+type gitConfig map[string][]string
+
+// get returns the last value of key, as git does for single-valued keys.
+func (c gitConfig) get(key string) string {
+	values := c[key]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+// readConfig parses the subset of the git config format found in
+// repository config files and .gitmodules. Include directives are ignored.
+func readConfig(path string) gitConfig {
+	cfg := gitConfig{}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cfg
+	}
+	section := ""
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				continue
+			}
+			// [remote "origin"] or the legacy [remote.origin]
+			name, sub, ok := strings.Cut(line[1:end], " ")
+			section = strings.ToLower(name)
+			if ok {
+				section += "." + strings.Trim(strings.TrimSpace(sub), `"`)
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			value = "true"
+		}
+		key = section + "." + strings.ToLower(strings.TrimSpace(key))
+		cfg[key] = append(cfg[key], configValue(value))
+	}
+	return cfg
+}
+
+// configValue unquotes a config value and strips a trailing comment.
+func configValue(v string) string {
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; {
+		case c == '"':
+			quoted = !quoted
+		case c == '\\' && i+1 < len(v):
+			i++
+			switch v[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(v[i])
+			}
+		case (c == '#' || c == ';') && !quoted:
+			return strings.TrimSpace(b.String())
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
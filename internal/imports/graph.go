@@ -0,0 +1,284 @@
+// Package imports builds the import graph of the packages in a Go module.
+package imports
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Package is one Go package of the module.
+type Package struct {
+	ImportPath string
+	Dir        string   // absolute directory
+	Files      []string // absolute paths of the package's .go files
+	Imports    []string // import paths of in-module packages, sorted
+}
+
+// Graph is the in-module import graph of a Go module.
+type Graph struct {
+	Module   string // module path from go.mod
+	Root     string // absolute module directory
+	Packages map[string]*Package
+}
+
+// FindModuleRoot returns the nearest directory at or above dir that holds
+// a go.mod file.
+func FindModuleRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("no go.mod found")
+		}
+		dir = parent
+	}
+}
+
+// ModulePath reads the module path declared in a go.mod file.
+func ModulePath(goMod string) (string, error) {
+	f, err := os.Open(goMod)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			rest = strings.TrimSpace(rest)
+			if i := strings.Index(rest, "//"); i >= 0 {
+				rest = strings.TrimSpace(rest[:i])
+			}
+			if unq, err := strconv.Unquote(rest); err == nil {
+				rest = unq
+			}
+			if rest != "" {
+				return rest, nil
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s: no module directive", goMod)
+}
+
+// Load parses the imports of every .go file below the module root. Vendor
+// and testdata directories, hidden directories and nested modules are
+// skipped, as the go command does.
+func Load(ctx context.Context, moduleRoot string) (*Graph, error) {
+	root, err := filepath.Abs(moduleRoot)
+	if err != nil {
+		return nil, err
+	}
+	module, err := ModulePath(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	g := &Graph{Module: module, Root: root, Packages: map[string]*Package{}}
+	imports := map[string]map[string]bool{}
+	fset := token.NewFileSet()
+
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if p != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return fs.SkipDir
+			}
+			if p != root {
+				if _, err := os.Stat(filepath.Join(p, "go.mod")); err == nil {
+					return fs.SkipDir
+				}
+			}
+			return nil
+		}
+		if !strings.HasSuffix(p, ".go") {
+			return nil
+		}
+		f, err := parser.ParseFile(fset, p, nil, parser.ImportsOnly)
+		if err != nil {
+			return nil // unparsable files do not contribute imports
+		}
+		dir := filepath.Dir(p)
+		ip := g.importPath(dir)
+		pkg := g.Packages[ip]
+		if pkg == nil {
+			pkg = &Package{ImportPath: ip, Dir: dir}
+			g.Packages[ip] = pkg
+			imports[ip] = map[string]bool{}
+		}
+		pkg.Files = append(pkg.Files, p)
+		for _, spec := range f.Imports {
+			dep, err := strconv.Unquote(spec.Path.Value)
+			if err == nil && g.inModule(dep) && dep != ip {
+				imports[ip][dep] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for ip, deps := range imports {
+		pkg := g.Packages[ip]
+		for dep := range deps {
+			if _, ok := g.Packages[dep]; ok {
+				pkg.Imports = append(pkg.Imports, dep)
+			}
+		}
+		sort.Strings(pkg.Imports)
+		sort.Strings(pkg.Files)
+	}
+	return g, nil
+}
+
+func (g *Graph) importPath(dir string) string {
+	rel, _ := filepath.Rel(g.Root, dir)
+	if rel == "." {
+		return g.Module
+	}
+	return path.Join(g.Module, filepath.ToSlash(rel))
+}
+
+func (g *Graph) inModule(importPath string) bool {
+	return importPath == g.Module || strings.HasPrefix(importPath, g.Module+"/")
+}
+
+// Find returns the package named by target: an import path, a .go file or
+// package directory on disk, or a path relative to the module root
+// ("internal/config").
+func (g *Graph) Find(target string) (*Package, error) {
+	if pkg, ok := g.Packages[target]; ok {
+		return pkg, nil
+	}
+	if info, err := os.Stat(target); err == nil {
+		dir, _ := filepath.Abs(target)
+		if !info.IsDir() {
+			dir = filepath.Dir(dir)
+		}
+		if pkg, ok := g.Packages[g.importPath(dir)]; ok {
+			return pkg, nil
+		}
+	}
+	if rel := path.Clean(filepath.ToSlash(target)); !path.IsAbs(rel) {
+		if pkg, ok := g.Packages[g.importPath(filepath.Join(g.Root, filepath.FromSlash(rel)))]; ok {
+			return pkg, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: no Go package of module %s", target, g.Module)
+}
+
+// Closure returns the import paths of the given packages and of every
+// in-module package they import, directly or indirectly, sorted. With
+// reverse set it also adds every package that imports one of them.
+func (g *Graph) Closure(start []string, reverse bool) []string {
+	seen := map[string]bool{}
+	var visit func(ip string)
+	visit = func(ip string) {
+		if seen[ip] {
+			return
+		}
+		seen[ip] = true
+		if pkg := g.Packages[ip]; pkg != nil {
+			for _, dep := range pkg.Imports {
+				visit(dep)
+			}
+		}
+	}
+	for _, ip := range start {
+		visit(ip)
+	}
+
+	if reverse {
+		importers := map[string][]string{}
+		for ip, pkg := range g.Packages {
+			for _, dep := range pkg.Imports {
+				importers[dep] = append(importers[dep], ip)
+			}
+		}
+		queue := append([]string(nil), start...)
+		for len(queue) > 0 {
+			ip := queue[0]
+			queue = queue[1:]
+			for _, user := range importers[ip] {
+				if !seen[user] {
+					seen[user] = true
+					queue = append(queue, user)
+				}
+			}
+		}
+	}
+
+	out := make([]string, 0, len(seen))
+	for ip := range seen {
+		out = append(out, ip)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Render formats the import graph of pkgs (all packages when nil) as one
+// line per package listing the in-module packages it imports. Paths are
+// shown relative to the module; "." is the module's root package.
+func (g *Graph) Render(pkgs []string) string {
+	if pkgs == nil {
+		for ip := range g.Packages {
+			pkgs = append(pkgs, ip)
+		}
+		sort.Strings(pkgs)
+	}
+	include := map[string]bool{}
+	for _, ip := range pkgs {
+		include[ip] = true
+	}
+	var b strings.Builder
+	for _, ip := range pkgs {
+		pkg := g.Packages[ip]
+		if pkg == nil {
+			continue
+		}
+		b.WriteString(g.short(ip))
+		var deps []string
+		for _, dep := range pkg.Imports {
+			if include[dep] {
+				deps = append(deps, g.short(dep))
+			}
+		}
+		if len(deps) > 0 {
+			b.WriteString(" -> ")
+			b.WriteString(strings.Join(deps, ", "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (g *Graph) short(ip string) string {
+	if ip == g.Module {
+		return "."
+	}
+	return strings.TrimPrefix(ip, g.Module+"/")
+}
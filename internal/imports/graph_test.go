@@ -0,0 +1,145 @@
+package imports
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeModule creates a module example.com/m in which a imports b, b
+// imports c and d imports a, together with files the graph must ignore.
+func writeModule(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":         "// The test module.\nmodule example.com/m // trailing comment\n\ngo 1.22\n",
+		"main.go":        "package main\n\nimport _ \"example.com/m/d\"\n",
+		"a/a.go":         "package a\n\nimport (\n\t\"fmt\"\n\t\"example.com/m/b\"\n\t\"example.com/mother/x\"\n)\n",
+		"a/a_test.go":    "package a\n\nimport \"testing\"\n",
+		"b/b.go":         "package b\n\nimport \"example.com/m/c\"\n",
+		"c/c.go":         "package c\n",
+		"d/d.go":         "package d\n\nimport \"example.com/m/a\"\n",
+		"broken/x.go":    "package broken\n\nimport \"example.com/m/c\n",
+		"vendor/v/v.go":  "package v\n\nimport \"example.com/m/c\"\n",
+		"testdata/t.go":  "package t\n",
+		".hidden/h.go":   "package h\n",
+		"nested/go.mod":  "module example.com/nested\n",
+		"nested/n.go":    "package nested\n\nimport \"example.com/m/a\"\n",
+		"docs/readme.md": "not go\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestLoad(t *testing.T) {
+	root := writeModule(t)
+	g, err := Load(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Module != "example.com/m" {
+		t.Errorf("Module = %q", g.Module)
+	}
+	// Vendor, testdata, hidden directories and nested modules are not
+	// part of the module, and unparsable files add no package.
+	imports := map[string][]string{}
+	for ip, pkg := range g.Packages {
+		imports[strings.TrimPrefix(ip, "example.com/m")] = pkg.Imports
+	}
+	want := map[string][]string{
+		"":   {"example.com/m/d"},
+		"/a": {"example.com/m/b"},
+		"/b": {"example.com/m/c"},
+		"/c": nil,
+		"/d": {"example.com/m/a"},
+	}
+	if !reflect.DeepEqual(imports, want) {
+		t.Errorf("imports = %v, want %v", imports, want)
+	}
+	if files := g.Packages["example.com/m/a"].Files; len(files) != 2 {
+		t.Errorf("files of a = %v, want a.go and a_test.go", files)
+	}
+
+	for _, target := range []string{"example.com/m/a", "a", "./a", filepath.Join(root, "a"), filepath.Join(root, "a", "a.go")} {
+		if pkg, err := g.Find(target); err != nil || pkg.ImportPath != "example.com/m/a" {
+			t.Errorf("Find(%q) = %v, %v; want example.com/m/a", target, pkg, err)
+		}
+	}
+	for _, target := range []string{"example.com/mother/x", "vendor/v", "nested", "docs"} {
+		if pkg, err := g.Find(target); err == nil {
+			t.Errorf("Find(%q) = %s, want an error", target, pkg.ImportPath)
+		}
+	}
+}
+
+func TestClosure(t *testing.T) {
+	g, err := Load(context.Background(), writeModule(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		start   []string
+		reverse bool
+		want    []string
+	}{
+		{[]string{"a"}, false, []string{"a", "b", "c"}},
+		{[]string{"c"}, false, []string{"c"}},
+		{[]string{"c"}, true, []string{".", "a", "b", "c", "d"}},
+		{[]string{"a"}, true, []string{".", "a", "b", "c", "d"}},
+		{[]string{"b", "d"}, false, []string{"a", "b", "c", "d"}},
+		{[]string{"."}, false, []string{".", "a", "b", "c", "d"}},
+	}
+	for _, tt := range tests {
+		var start []string
+		for _, s := range tt.start {
+			pkg, err := g.Find(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			start = append(start, pkg.ImportPath)
+		}
+		var got []string
+		for _, ip := range g.Closure(start, tt.reverse) {
+			got = append(got, g.short(ip))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Closure(%v, %v) = %v, want %v", tt.start, tt.reverse, got, tt.want)
+		}
+	}
+	if got, want := g.Render(g.Closure([]string{"example.com/m/a"}, false)), "a -> b\nb -> c\nc\n"; got != want {
+		t.Errorf("Render =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestModulePath(t *testing.T) {
+	tests := []struct {
+		content, want string
+	}{
+		{"module example.com/m\n", "example.com/m"},
+		{"module \"example.com/quoted\"\ngo 1.22\n", "example.com/quoted"},
+		{"// modules are great\nmodule\texample.com/tab // note\n", "example.com/tab"},
+		{"modules example.com/no\n", ""},
+		{"go 1.22\n", ""},
+	}
+	for _, tt := range tests {
+		p := filepath.Join(t.TempDir(), "go.mod")
+		if err := os.WriteFile(p, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ModulePath(p)
+		if got != tt.want || (err != nil) != (tt.want == "") {
+			t.Errorf("ModulePath(%q) = %q, %v; want %q", tt.content, got, err, tt.want)
+		}
+	}
+}
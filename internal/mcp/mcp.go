@@ -0,0 +1,217 @@
+// Package mcp implements a Model Context Protocol server that lets LLM
+// agents explore and pack a repository over JSON-RPC.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/config"
+	"github.com/AndersonTsaiTW/RepoGo/repogo"
+)
+
+// ProtocolVersion is the newest MCP revision the server speaks.
+const ProtocolVersion = "2025-06-18"
+
+// supportedVersions lists the revisions accepted from clients, newest first.
+var supportedVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// Server answers MCP requests about a single repository root.
+type Server struct {
+	root     string // absolute, symlink-free
+	defaults repogo.Options
+	tools    []tool
+}
+
+// New creates a Server for the repository at root. defaults supplies the
+// options (globs, limits, callbacks) that every tool call starts from.
+func New(root string, defaults repogo.Options) (*Server, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if abs, err = filepath.EvalSymlinks(abs); err != nil {
+		return nil, err
+	}
+	s := &Server{root: abs, defaults: defaults}
+	s.tools = s.builtinTools()
+	return s, nil
+}
+
+// Serve reads newline-delimited JSON-RPC messages from r and writes the
+// responses to w until r is exhausted or ctx is cancelled.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadBytes('\n')
+			if len(line) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case line := <-lines:
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			if resp := s.handle(ctx, line); resp != nil {
+				if err := enc.Encode(resp); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// handle processes one message and returns the response to send, or nil
+// for notifications.
+func (s *Server) handle(ctx context.Context, line []byte) *response {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, "parse error: " + err.Error()}}
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if req.ID == nil {
+			return nil
+		}
+		return &response{JSONRPC: "2.0", ID: req.ID, Error: &rpcError{codeInvalidRequest, "invalid request"}}
+	}
+
+	result, err := s.dispatch(ctx, req)
+	if req.ID == nil {
+		return nil // notification
+	}
+	resp := &response{JSONRPC: "2.0", ID: req.ID, Result: result}
+	if err != nil {
+		var re *rpcError
+		if !errors.As(err, &re) {
+			re = &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		resp.Result, resp.Error = nil, re
+	}
+	return resp
+}
+
+func (s *Server) dispatch(ctx context.Context, req request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		var p struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &p)
+		version := ProtocolVersion
+		for _, v := range supportedVersions {
+			if v == p.ProtocolVersion {
+				version = v
+			}
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": "repogo", "version": config.Version},
+			"instructions":    "Tools for exploring the repository at " + s.root + ". Paths are relative to it.",
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		list := make([]map[string]any, 0, len(s.tools))
+		for _, t := range s.tools {
+			list = append(list, map[string]any{
+				"name":        t.name,
+				"description": t.description,
+				"inputSchema": t.schema,
+			})
+		}
+		return map[string]any{"tools": list}, nil
+	case "tools/call":
+		var p struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &rpcError{codeInvalidParams, "invalid params: " + err.Error()}
+		}
+		t := s.findTool(p.Name)
+		if t == nil {
+			return nil, &rpcError{codeInvalidParams, fmt.Sprintf("unknown tool %q", p.Name)}
+		}
+		if len(p.Arguments) == 0 || string(p.Arguments) == "null" {
+			p.Arguments = json.RawMessage("{}")
+		}
+		// Tool failures are reported to the model, not as protocol errors.
+		text, err := t.call(ctx, p.Arguments)
+		if err != nil {
+			return toolResult(err.Error(), true), nil
+		}
+		return toolResult(text, false), nil
+	default:
+		if strings.HasPrefix(req.Method, "notifications/") {
+			return nil, nil
+		}
+		return nil, &rpcError{codeMethodNotFound, fmt.Sprintf("method %q not found", req.Method)}
+	}
+}
+
+func toolResult(text string, isError bool) map[string]any {
+	return map[string]any{
+		"content": []map[string]string{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
@@ -0,0 +1,360 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AndersonTsaiTW/RepoGo/repogo"
+)
+
+// testResponse is a decoded JSON-RPC response.
+type testResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// newTestServer serves a small repository whose defaults truncate, compress
+// and deduplicate packed contents, as a server started with such flags would.
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	root := t.TempDir()
+	big := strings.Repeat("filler line\n", 20000) + "NEEDLE\n"
+	files := map[string]string{
+		"a.go":       "package a\n\n// NEEDLE in a comment\nfunc A() {}\n",
+		"copy/a.go":  "package a\n\n// NEEDLE in a comment\nfunc A() {}\n",
+		"gen.go":     "// Code generated by stringer. DO NOT EDIT.\n\npackage a\n\nconst NEEDLE = 1\n",
+		"big.txt":    big,
+		"image.bin":  "NEEDLE\x00\x01",
+		"docs/r.txt": "nothing to see\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := New(root, repogo.Options{
+		MaxFileSize: 1024,
+		Compress:    repogo.CompressComments,
+		Dedupe:      true,
+		Generated:   repogo.GeneratedStub,
+		SkipGit:     true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// serve runs input through s and returns the decoded responses.
+func serve(t *testing.T, s *Server, input string) []testResponse {
+	t.Helper()
+	var out bytes.Buffer
+	if err := s.Serve(context.Background(), strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	var resps []testResponse
+	dec := json.NewDecoder(&out)
+	for {
+		var r testResponse
+		if err := dec.Decode(&r); err == io.EOF {
+			return resps
+		} else if err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		resps = append(resps, r)
+	}
+}
+
+// toolText returns the text of a tools/call result and whether it is an error.
+func toolText(t *testing.T, r testResponse) (string, bool) {
+	t.Helper()
+	var result struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	}
+	if err := json.Unmarshal(r.Result, &result); err != nil || len(result.Content) != 1 {
+		t.Fatalf("unexpected tool result %s (%v)", r.Result, err)
+	}
+	return result.Content[0].Text, result.IsError
+}
+
+func call(tool, args string) string {
+	return `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"` + tool + `","arguments":` + args + `}}`
+}
+
+func TestServe(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		name    string
+		request string
+		code    int      // expected error code, 0 for a result
+		result  []string // substrings of the raw result
+	}{
+		{
+			name:    "initialize",
+			request: `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+			result:  []string{`"protocolVersion":"2024-11-05"`, `"name":"repogo"`, `"tools":{}`},
+		},
+		{
+			name:    "initialize with an unknown version",
+			request: `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`,
+			result:  []string{`"protocolVersion":"` + ProtocolVersion + `"`},
+		},
+		{
+			name:    "ping",
+			request: `{"jsonrpc":"2.0","id":1,"method":"ping"}`,
+			result:  []string{`{}`},
+		},
+		{
+			name:    "tools/list",
+			request: `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+			result:  []string{`"name":"list_tree"`, `"name":"read_files"`, `"name":"search"`, `"name":"pack"`, `"name":"git_info"`, `"inputSchema"`},
+		},
+		{
+			name:    "unknown method",
+			request: `{"jsonrpc":"2.0","id":1,"method":"resources/list"}`,
+			code:    codeMethodNotFound,
+		},
+		{
+			name:    "parse error",
+			request: `{"jsonrpc":"2.0","id":1,`,
+			code:    codeParseError,
+		},
+		{
+			name:    "invalid request",
+			request: `{"jsonrpc":"1.0","id":1,"method":"ping"}`,
+			code:    codeInvalidRequest,
+		},
+		{
+			name:    "unknown tool",
+			request: call("rm_rf", `{}`),
+			code:    codeInvalidParams,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resps := serve(t, s, tt.request+"\n")
+			if len(resps) != 1 {
+				t.Fatalf("got %d responses, want 1", len(resps))
+			}
+			r := resps[0]
+			if tt.code != 0 {
+				if r.Error == nil || r.Error.Code != tt.code {
+					t.Fatalf("error = %+v, want code %d", r.Error, tt.code)
+				}
+				return
+			}
+			if r.Error != nil {
+				t.Fatalf("unexpected error %+v", r.Error)
+			}
+			for _, want := range tt.result {
+				if !strings.Contains(string(r.Result), want) {
+					t.Errorf("result %s does not contain %s", r.Result, want)
+				}
+			}
+		})
+	}
+}
+
+func TestServeTools(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		name    string
+		tool    string
+		args    string
+		isError bool
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "list_tree",
+			tool: "list_tree",
+			args: `{"depth":1}`,
+			want: []string{"a.go", "copy/", "docs/"},
+		},
+		{
+			name:    "list_tree outside the root",
+			tool:    "list_tree",
+			args:    `{"path":"../"}`,
+			isError: true,
+		},
+		{
+			name:    "read_files",
+			tool:    "read_files",
+			args:    `{"paths":["docs"],"line_numbers":true}`,
+			want:    []string{"### File: docs/r.txt", "1 | nothing to see"},
+			notWant: []string{"a.go"},
+		},
+		{
+			name:    "read_files without paths",
+			tool:    "read_files",
+			args:    `{}`,
+			isError: true,
+		},
+		{
+			name:    "read_files with unknown arguments",
+			tool:    "read_files",
+			args:    `{"paths":["a.go"],"recursive":true}`,
+			isError: true,
+		},
+		{
+			name: "search reads whole, unprocessed files",
+			tool: "search",
+			args: `{"pattern":"needle","ignore_case":true}`,
+			want: []string{
+				"a.go:3: // NEEDLE in a comment",
+				"copy/a.go:3: // NEEDLE in a comment",
+				"gen.go:5: const NEEDLE = 1",
+				"big.txt:20001: NEEDLE",
+			},
+			notWant: []string{"image.bin", "stopped"},
+		},
+		{
+			name:    "search with globs",
+			tool:    "search",
+			args:    `{"pattern":"NEEDLE","include":["*.txt"]}`,
+			want:    []string{"big.txt:20001: NEEDLE"},
+			notWant: []string{"a.go"},
+		},
+		{
+			name: "search stops at max_results",
+			tool: "search",
+			args: `{"pattern":"NEEDLE","max_results":2}`,
+			want: []string{"(stopped after 2 matches)"},
+		},
+		{
+			name:    "search exactly max_results",
+			tool:    "search",
+			args:    `{"pattern":"NEEDLE","include":["*.txt"],"max_results":1}`,
+			want:    []string{"big.txt:20001: NEEDLE"},
+			notWant: []string{"stopped"},
+		},
+		{
+			name: "search without matches",
+			tool: "search",
+			args: `{"pattern":"haystack"}`,
+			want: []string{"No matches."},
+		},
+		{
+			name:    "search with an invalid pattern",
+			tool:    "search",
+			args:    `{"pattern":"("}`,
+			isError: true,
+		},
+		{
+			name:    "pack",
+			tool:    "pack",
+			args:    `{"paths":["docs"]}`,
+			want:    []string{"# Repository Context", "### File: docs/r.txt", "nothing to see"},
+			notWant: []string{"big.txt"},
+		},
+		{
+			name: "pack as JSON",
+			tool: "pack",
+			args: `{"paths":["docs"],"format":"json"}`,
+			want: []string{`"path": "docs/r.txt"`},
+		},
+		{
+			name:    "git_info outside a repository",
+			tool:    "git_info",
+			args:    `{}`,
+			isError: true,
+			want:    []string{"not a git repository"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resps := serve(t, s, call(tt.tool, tt.args)+"\n")
+			if len(resps) != 1 || resps[0].Error != nil {
+				t.Fatalf("responses = %+v, want one result", resps)
+			}
+			text, isError := toolText(t, resps[0])
+			if isError != tt.isError {
+				t.Fatalf("isError = %v, want %v; text:\n%s", isError, tt.isError, text)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("text does not contain %q:\n%s", want, text)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(text, notWant) {
+					t.Errorf("text contains %q:\n%s", notWant, text)
+				}
+			}
+		})
+	}
+}
+
+// TestServeSession runs a scripted session over a pipe: responses come back
+// in order, and notifications are not answered.
+func TestServeSession(t *testing.T) {
+	s := newTestServer(t)
+	pr, pw := io.Pipe()
+	var out bytes.Buffer
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(context.Background(), pr, &out) }()
+
+	for _, line := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		``,
+		`{"jsonrpc":"2.0","id":"two","method":"tools/list"}`,
+		`{"jsonrpc":"2.0","method":"ping"}`,
+		`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1}}`,
+		call("search", `{"pattern":"NEEDLE","include":["*.txt"]}`),
+	} {
+		if _, err := io.WriteString(pw, line+"\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pw.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+
+	var ids []string
+	dec := json.NewDecoder(&out)
+	for {
+		var r testResponse
+		if err := dec.Decode(&r); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if r.Error != nil {
+			t.Errorf("response %s: unexpected error %+v", r.ID, r.Error)
+		}
+		ids = append(ids, string(r.ID))
+	}
+	if got, want := strings.Join(ids, " "), `1 "two" 1`; got != want {
+		t.Errorf("response ids = %s, want %s", got, want)
+	}
+}
+
+func TestServeCancel(t *testing.T) {
+	s := newTestServer(t)
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ctx, pr, io.Discard) }()
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Serve = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,387 @@
+// Package mcp implements a Model Context Protocol server that lets LLM
+// agents explore and pack a repository over JSON-RPC.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/git"
+	"github.com/AndersonTsaiTW/RepoGo/internal/renderer"
+	"github.com/AndersonTsaiTW/RepoGo/internal/scanner"
+	"github.com/AndersonTsaiTW/RepoGo/repogo"
+)
+
+// defaultMaxResults caps search output when the caller sets no limit.
+const defaultMaxResults = 100
+
+// binarySniffLen is how much of a file search inspects for NUL bytes to
+// recognize binary files.
+const binarySniffLen = 8000
+
+// tool is one callable MCP tool.
+type tool struct {
+	name        string
+	description string
+	schema      map[string]any // JSON Schema of the arguments
+	call        func(ctx context.Context, args json.RawMessage) (string, error)
+}
+
+func (s *Server) findTool(name string) *tool {
+	for i := range s.tools {
+		if s.tools[i].name == name {
+			return &s.tools[i]
+		}
+	}
+	return nil
+}
+
+// object builds a JSON Schema object from property schemas.
+func object(props map[string]any, required ...string) map[string]any {
+	schema := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func prop(typ, description string) map[string]any {
+	return map[string]any{"type": typ, "description": description}
+}
+
+func stringList(description string) map[string]any {
+	return map[string]any{"type": "array", "items": map[string]string{"type": "string"}, "description": description}
+}
+
+func (s *Server) builtinTools() []tool {
+	return []tool{
+		{
+			name:        "list_tree",
+			description: "Show the directory tree of the repository or a subdirectory, optionally annotated with sizes, lines and estimated tokens.",
+			schema: object(map[string]any{
+				"path":     prop("string", "directory relative to the repository root (default: root)"),
+				"depth":    prop("integer", "collapse levels deeper than this (0 = no limit)"),
+				"annotate": prop("boolean", "annotate entries with size, lines and estimated tokens"),
+				"include":  stringList("glob patterns of files to include"),
+				"exclude":  stringList("glob patterns of files and directories to exclude"),
+			}),
+			call: s.listTree,
+		},
+		{
+			name:        "read_files",
+			description: "Read the contents of files (or every file below directories) in the repository.",
+			schema: object(map[string]any{
+				"paths":         stringList("files or directories relative to the repository root"),
+				"max_file_size": prop("integer", "bytes read per file before truncation"),
+				"line_numbers":  prop("boolean", "prefix each line with its line number in the file"),
+			}, "paths"),
+			call: s.readFiles,
+		},
+		{
+			name:        "search",
+			description: "Search file contents with a regular expression and return matching lines as path:line: text.",
+			schema: object(map[string]any{
+				"pattern":     prop("string", "regular expression (RE2 syntax)"),
+				"ignore_case": prop("boolean", "match case-insensitively"),
+				"include":     stringList("glob patterns of files to search"),
+				"exclude":     stringList("glob patterns of files and directories to skip"),
+				"max_results": prop("integer", fmt.Sprintf("maximum number of matching lines (default %d)", defaultMaxResults)),
+			}, "pattern"),
+			call: s.search,
+		},
+		{
+			name:        "pack",
+			description: "Pack files, structure and git metadata into a single document, stopping once the token budget is reached.",
+			schema: object(map[string]any{
+				"paths":      stringList("files or directories relative to the repository root (default: root)"),
+				"include":    stringList("glob patterns of files to include"),
+				"exclude":    stringList("glob patterns of files and directories to exclude"),
+				"max_tokens": prop("integer", "estimated token budget for file contents (0 = no limit)"),
+				"query":      prop("string", "pack the files most relevant to this text first"),
+				"format":     prop("string", "output format: markdown or json (default markdown)"),
+			}),
+			call: s.pack,
+		},
+		{
+			name:        "git_info",
+			description: "Report the current commit, branch, author and date of the repository, with its upstream, tags, remotes and submodules.",
+			schema:      object(map[string]any{}),
+			call:        s.gitInfo,
+		},
+	}
+}
+
+// options returns the defaults for a tool call with extra globs applied.
+func (s *Server) options(include, exclude []string) repogo.Options {
+	opts := s.defaults
+	opts.Root = s.root
+	opts.Paths = []string{s.root}
+	opts.SkipGit = true
+	opts.MaxTokens = 0 // only the pack tool applies a budget
+	opts.Include = append(append([]string(nil), opts.Include...), include...)
+	opts.Exclude = append(append([]string(nil), opts.Exclude...), exclude...)
+	opts.Filters = append(append([]repogo.Filter(nil), opts.Filters...), repogo.FilterFunc(scanner.ContainedSymlinks(s.root)))
+	return opts
+}
+
+// resolveAll maps relative paths onto the root; empty input selects the root.
+func (s *Server) resolveAll(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return []string{s.root}, nil
+	}
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if p == "." || p == "" {
+			out = append(out, s.root)
+			continue
+		}
+		abs, err := scanner.Resolve(s.root, p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		out = append(out, abs)
+	}
+	return out, nil
+}
+
+func decode(args json.RawMessage, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) listTree(ctx context.Context, raw json.RawMessage) (string, error) {
+	var args struct {
+		Path     string   `json:"path"`
+		Depth    int      `json:"depth"`
+		Annotate bool     `json:"annotate"`
+		Include  []string `json:"include"`
+		Exclude  []string `json:"exclude"`
+	}
+	if err := decode(raw, &args); err != nil {
+		return "", err
+	}
+	opts := s.options(args.Include, args.Exclude)
+	paths, err := s.resolveAll([]string{args.Path})
+	if err != nil {
+		return "", err
+	}
+	opts.Paths = paths
+	opts.Tree = repogo.TreeOptions{MaxDepth: args.Depth, Annotate: args.Annotate}
+	tree, err := repogo.Tree(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	return scanner.RenderTree(tree, opts.Tree), nil
+}
+
+func (s *Server) readFiles(ctx context.Context, raw json.RawMessage) (string, error) {
+	var args struct {
+		Paths       []string `json:"paths"`
+		MaxFileSize int      `json:"max_file_size"`
+		LineNumbers bool     `json:"line_numbers"`
+	}
+	if err := decode(raw, &args); err != nil {
+		return "", err
+	}
+	if len(args.Paths) == 0 {
+		return "", errors.New("paths is required")
+	}
+	opts := s.options(nil, nil)
+	paths, err := s.resolveAll(args.Paths)
+	if err != nil {
+		return "", err
+	}
+	opts.Paths = paths
+	opts.LineNumbers = args.LineNumbers
+	if args.MaxFileSize > 0 {
+		opts.MaxFileSize = args.MaxFileSize
+	}
+	doc, err := repogo.Pack(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, f := range doc.Files {
+		fmt.Fprintf(&b, "### File: %s\n", f.Path)
+		switch {
+		case f.ReadErrorMessage != "":
+			fmt.Fprintf(&b, "(error: %s)\n\n", f.ReadErrorMessage)
+		case f.Omitted != "":
+			fmt.Fprintf(&b, "(omitted: %s)\n\n", f.Omitted)
+		case f.IsBinary:
+			b.WriteString("(binary file omitted)\n\n")
+		default:
+			content := f.Content
+			if len(f.LineRanges) > 0 {
+				content = renderer.NumberLines(content, f.LineRanges)
+			}
+			fmt.Fprintf(&b, "```%s\n%s", f.LanguageHint, content)
+			if !strings.HasSuffix(content, "\n") {
+				b.WriteString("\n")
+			}
+			b.WriteString("```\n")
+			if f.Truncated {
+				b.WriteString("(truncated)\n")
+			}
+			b.WriteString("\n")
+		}
+	}
+	if b.Len() == 0 {
+		return "No files matched.", nil
+	}
+	return b.String(), nil
+}
+
+func (s *Server) search(ctx context.Context, raw json.RawMessage) (string, error) {
+	var args struct {
+		Pattern    string   `json:"pattern"`
+		IgnoreCase bool     `json:"ignore_case"`
+		Include    []string `json:"include"`
+		Exclude    []string `json:"exclude"`
+		MaxResults int      `json:"max_results"`
+	}
+	if err := decode(raw, &args); err != nil {
+		return "", err
+	}
+	if args.Pattern == "" {
+		return "", errors.New("pattern is required")
+	}
+	expr := args.Pattern
+	if args.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return "", err
+	}
+	if args.MaxResults <= 0 {
+		args.MaxResults = defaultMaxResults
+	}
+
+	// Search the files themselves: packed contents may be truncated,
+	// compressed, deduplicated or stubbed by the server's defaults.
+	files, err := repogo.Files(ctx, s.options(args.Include, args.Exclude))
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	matches := 0
+	for _, p := range files {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		rel, _ := filepath.Rel(s.root, p)
+		n, err := searchFile(p, filepath.ToSlash(rel), re, args.MaxResults-matches, &b)
+		if err != nil {
+			continue // unreadable, as when packing
+		}
+		if matches+n > args.MaxResults {
+			fmt.Fprintf(&b, "(stopped after %d matches)\n", args.MaxResults)
+			return b.String(), nil
+		}
+		matches += n
+	}
+	if matches == 0 {
+		return "No matches.", nil
+	}
+	return b.String(), nil
+}
+
+// searchFile writes up to limit lines of the file at p matching re to b, as
+// rel:line: text, and returns their number, or limit+1 if there are more.
+// Binary files have no matches.
+func searchFile(p, rel string, re *regexp.Regexp, limit int, b *strings.Builder) (int, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	if head, _ := br.Peek(binarySniffLen); bytes.IndexByte(head, 0) >= 0 {
+		return 0, nil
+	}
+	matches := 0
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		if line == "" && err != nil {
+			break
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if re.MatchString(line) {
+			if matches == limit {
+				return matches + 1, nil
+			}
+			matches++
+			fmt.Fprintf(b, "%s:%d: %s\n", rel, n, line)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return matches, nil
+}
+
+func (s *Server) pack(ctx context.Context, raw json.RawMessage) (string, error) {
+	var args struct {
+		Paths     []string `json:"paths"`
+		Include   []string `json:"include"`
+		Exclude   []string `json:"exclude"`
+		MaxTokens int      `json:"max_tokens"`
+		Query     string   `json:"query"`
+		Format    string   `json:"format"`
+	}
+	if err := decode(raw, &args); err != nil {
+		return "", err
+	}
+	opts := s.options(args.Include, args.Exclude)
+	paths, err := s.resolveAll(args.Paths)
+	if err != nil {
+		return "", err
+	}
+	opts.Paths = paths
+	opts.SkipGit = s.defaults.SkipGit
+	opts.MaxTokens = s.defaults.MaxTokens
+	if args.MaxTokens > 0 {
+		opts.MaxTokens = args.MaxTokens
+	}
+	if args.Query != "" {
+		opts.Query = args.Query
+	}
+	if args.Format == "" {
+		args.Format = "markdown"
+	}
+	doc, err := repogo.Pack(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := repogo.Render(&b, strings.ToLower(args.Format), doc); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (s *Server) gitInfo(ctx context.Context, raw json.RawMessage) (string, error) {
+	gi, err := git.GetInfo(s.root)
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %w", err)
+	}
+	out, err := json.MarshalIndent(gi, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -1,39 +1,253 @@
 // Package models defines the core data structures used throughout the application.
 package models
 
-// GitInfo contains information about the Git repository.
+// GitInfo contains information about the Git repository. Branch is empty
+// and Detached set when HEAD does not point at a branch. Upstream, Ahead and
+// Behind describe the branch's remote-tracking branch; Tags lists the tags
+// pointing at HEAD.
 type GitInfo struct {
-	Commit string `json:"commit"`
-	Branch string `json:"branch"`
-	Author string `json:"author"`
-	Date   string `json:"date"`
+	Commit     string      `json:"commit"`
+	Branch     string      `json:"branch"`
+	Detached   bool        `json:"detached,omitempty"`
+	Author     string      `json:"author"`
+	Date       string      `json:"date"`
+	Upstream   string      `json:"upstream,omitempty"`
+	Ahead      int         `json:"ahead,omitempty"`
+	Behind     int         `json:"behind,omitempty"`
+	Tags       []string    `json:"tags,omitempty"`
+	Remotes    []Remote    `json:"remotes,omitempty"`
+	Submodules []Submodule `json:"submodules,omitempty"`
 }
 
-// FileEntry represents a single file in the repository.
+// Remote is a configured remote with credentials removed from its URL.
+type Remote struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Submodule is a submodule registered in the repository and the commit it
+// is pinned to. Status is "uninitialized", "modified" (checked out at a
+// different commit), "conflict", or empty when it matches. Git describes
+// the checkout of an initialized submodule.
+type Submodule struct {
+	Path   string   `json:"path"`
+	Commit string   `json:"commit"`
+	Status string   `json:"status,omitempty"`
+	Git    *GitInfo `json:"git,omitempty"`
+}
+
+// Commit is one entry of the recent history.
+type Commit struct {
+	Hash    string       `json:"hash"`
+	Author  string       `json:"author"`
+	Date    string       `json:"date"`
+	Subject string       `json:"subject"`
+	Files   []FileChange `json:"files,omitempty"`
+}
+
+// FileChange counts the lines a commit added to and deleted from one file.
+type FileChange struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+	Binary  bool   `json:"binary,omitempty"`
+}
+
+// FileEntry represents a single file in the repository. Omitted explains
+// why Content was deliberately left out, for example for lock files.
+// Symlink is the target of a symbolic link recorded without reading it.
+// Duplicate refers to an earlier file with the same or nearly the same
+// content, which is then packed only once. TokensSaved estimates the tokens
+// removed from Content by compression. LineRanges gives the original line
+// numbers of Content: its lines correspond, in order, to the lines of each
+// range in turn.
 type FileEntry struct {
-	Path             string `json:"path"`
-	Size             int64  `json:"size"`
-	IsBinary         bool   `json:"is_binary"`
-	Truncated        bool   `json:"truncated"`
-	LanguageHint     string `json:"language_hint,omitempty"`
-	Content          string `json:"content,omitempty"`
-	ReadErrorMessage string `json:"read_error_message,omitempty"`
+	Path             string       `json:"path"`
+	Size             int64        `json:"size"`
+	IsBinary         bool         `json:"is_binary"`
+	Truncated        bool         `json:"truncated"`
+	Language         string       `json:"language,omitempty"`
+	LanguageHint     string       `json:"language_hint,omitempty"`
+	Content          string       `json:"content,omitempty"`
+	LineRanges       []LineRange  `json:"line_ranges,omitempty"`
+	Snippets         []Snippet    `json:"snippets,omitempty"`
+	Omitted          string       `json:"omitted,omitempty"`
+	Symlink          string       `json:"symlink,omitempty"`
+	Duplicate        *Duplicate   `json:"duplicate,omitempty"`
+	TokensSaved      int          `json:"tokens_saved,omitempty"`
+	ReadErrorMessage string       `json:"read_error_message,omitempty"`
+	Score            float64      `json:"score,omitempty"`
+	History          *FileHistory `json:"history,omitempty"`
+}
+
+// Duplicate refers a file to an earlier one in the output. Similarity is
+// the share of lines they have in common, 1 for identical files; for near
+// duplicates Diff is a unified diff turning the earlier file into this one.
+type Duplicate struct {
+	Of         string  `json:"of"`
+	Similarity float64 `json:"similarity"`
+	Diff       string  `json:"diff,omitempty"`
 }
 
-// Summary contains statistics about the scanned repository.
+// LineRange is a run of lines of a file, 1-based and inclusive.
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// FileHistory is the git history of one file: its last commit, and the
+// commits and distinct authors within the requested window.
+type FileHistory struct {
+	LastCommit string `json:"last_commit"`
+	LastDate   string `json:"last_date"`
+	LastAuthor string `json:"last_author"`
+	Commits    int    `json:"commits"`
+	Authors    int    `json:"authors"`
+}
+
+// Snippet is a region of a file, such as the lines around a search match.
+// Line numbers are 1-based and inclusive.
+type Snippet struct {
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Content   string `json:"content"`
+}
+
+// Summary contains statistics about the scanned repository. Skipped counts
+// the files whose content was left out by kind, such as "generated",
+// "minified" or "vendored". Deduplicated counts the files packed as
+// references to an earlier duplicate, and DedupeSavedTokens the estimated
+// tokens this saved; CompressSavedTokens is the total removed by compression.
+// Excluded counts the paths left out by the modification time, size and
+// depth limits, keyed by reason, such as "larger than 204800 bytes".
 type Summary struct {
-	TotalFiles       int `json:"total_files"`
-	TotalLines       int `json:"total_lines"`
-	EstimatedTokens  int `json:"estimated_tokens"`
-	SkippedByLimit   int `json:"skipped_by_token_limit"`
-	BinaryFilesCount int `json:"binary_files"`
+	TotalFiles          int            `json:"total_files"`
+	TotalLines          int            `json:"total_lines"`
+	EstimatedTokens     int            `json:"estimated_tokens"`
+	SkippedByLimit      int            `json:"skipped_by_token_limit"`
+	BinaryFilesCount    int            `json:"binary_files"`
+	Skipped             map[string]int `json:"skipped,omitempty"`
+	Excluded            map[string]int `json:"excluded,omitempty"`
+	Deduplicated        int            `json:"deduplicated,omitempty"`
+	DedupeSavedTokens   int            `json:"dedupe_saved_tokens,omitempty"`
+	CompressSavedTokens int            `json:"compress_saved_tokens,omitempty"`
 }
 
-// OutputDoc is the complete document structure for output.
+// OutputDoc is the complete document structure for output. ImportGraph
+// lists Go packages with the in-module packages each imports.
 type OutputDoc struct {
-	Location  string      `json:"location"`
-	Git       *GitInfo    `json:"git,omitempty"`
-	Structure string      `json:"structure"`
-	Files     []FileEntry `json:"files"`
-	Summary   Summary     `json:"summary"`
+	Location     string        `json:"location"`
+	Git          *GitInfo      `json:"git,omitempty"`
+	Log          []Commit      `json:"log,omitempty"`
+	Structure    string        `json:"structure"`
+	ImportGraph  string        `json:"import_graph,omitempty"`
+	Dependencies *Dependencies `json:"dependencies,omitempty"`
+	Diff         string        `json:"diff,omitempty"`
+	Query        string        `json:"query,omitempty"`
+	Files        []FileEntry   `json:"files"`
+	Summary      Summary       `json:"summary"`
+	Stats        *Stats        `json:"stats,omitempty"`
+}
+
+// Dependencies summarizes the dependency manifests and lock files found.
+type Dependencies struct {
+	Manifests []Manifest `json:"manifests,omitempty"`
+	LockFiles []LockFile `json:"lock_files,omitempty"`
+}
+
+// Manifest lists the direct dependencies declared in one manifest file,
+// such as go.mod or package.json.
+type Manifest struct {
+	Path         string       `json:"path"`
+	Ecosystem    string       `json:"ecosystem"`
+	Name         string       `json:"name,omitempty"`
+	Dependencies []Dependency `json:"dependencies"`
+}
+
+// Dependency is one declared dependency. Scope distinguishes groups such as
+// "dev", "test" or "indirect"; it is empty for regular dependencies.
+type Dependency struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Scope   string `json:"scope,omitempty"`
+}
+
+// LockFile records how many packages a lock file pins.
+type LockFile struct {
+	Path      string `json:"path"`
+	Ecosystem string `json:"ecosystem"`
+	Packages  int    `json:"packages"`
+}
+
+// Stats breaks down the scanned files by language and directory.
+type Stats struct {
+	ByLanguage          []GroupStats `json:"by_language"`
+	ByDirectory         []GroupStats `json:"by_directory"`
+	LargestFiles        []FileStats  `json:"largest_files"`
+	HeaviestDirectories []GroupStats `json:"heaviest_directories"`
+	Hotspots            []FileStats  `json:"hotspots,omitempty"`
+}
+
+// GroupStats aggregates line, byte and token counts for a group of files,
+// such as all files of one language or below one directory.
+type GroupStats struct {
+	Name         string `json:"name"`
+	Files        int    `json:"files"`
+	CodeLines    int    `json:"code_lines"`
+	CommentLines int    `json:"comment_lines"`
+	BlankLines   int    `json:"blank_lines"`
+	Bytes        int64  `json:"bytes"`
+	Tokens       int    `json:"tokens"`
+}
+
+// FileStats holds the size figures of a single file.
+type FileStats struct {
+	Path     string `json:"path"`
+	Language string `json:"language,omitempty"`
+	Bytes    int64  `json:"bytes"`
+	Lines    int    `json:"lines"`
+	Tokens   int    `json:"tokens"`
+	Commits  int    `json:"commits,omitempty"`
+	Authors  int    `json:"authors,omitempty"`
+}
+
+// TreeNode is a node of the directory structure. Directory nodes aggregate
+// the figures of all files below them. Note marks entries whose contents
+// were not scanned, such as stubbed submodules.
+type TreeNode struct {
+	Name     string      `json:"name"`
+	Path     string      `json:"path"`
+	IsDir    bool        `json:"is_dir"`
+	Files    int         `json:"files,omitempty"`
+	Size     int64       `json:"size"`
+	Lines    int         `json:"lines"`
+	Tokens   int         `json:"tokens"`
+	Note     string      `json:"note,omitempty"`
+	Children []*TreeNode `json:"children,omitempty"`
+}
+
+// Decisions taken for a path, as reported by explain mode.
+const (
+	DecisionIncluded = "included" // packed with its content
+	DecisionStubbed  = "stubbed"  // listed, with a note instead of its content
+	DecisionOmitted  = "omitted"  // selected, but cut by the token budget
+	DecisionExcluded = "excluded" // left out of the scan
+)
+
+// Decision records what happened to one path and the rule responsible.
+// Rule names the kind of rule, such as "exclude", "preset", "limit" or
+// "budget", and Detail the rule itself, such as the matching glob.
+type Decision struct {
+	Path     string `json:"path"`
+	IsDir    bool   `json:"is_dir,omitempty"`
+	Decision string `json:"decision"`
+	Rule     string `json:"rule,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// Explanation lists the decisions taken for every path a scan considered,
+// sorted by path.
+type Explanation struct {
+	Location  string     `json:"location"`
+	Decisions []Decision `json:"decisions"`
 }
@@ -0,0 +1,150 @@
+// Package rank scores files by their relevance to a free-text query.
+package rank
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters.
+const (
+	k1 = 1.2
+	b  = 0.75
+	// pathWeight counts each term of a file's path this many times, so that
+	// a query naming a file or directory ranks it above passing mentions.
+	pathWeight = 3
+)
+
+type document struct {
+	freqs  map[string]int
+	length int
+}
+
+// Index is an in-memory BM25 index over file paths and contents.
+type Index struct {
+	docs     []document
+	df       map[string]int
+	totalLen int
+}
+
+// NewIndex returns an empty index.
+func NewIndex() *Index {
+	return &Index{df: make(map[string]int)}
+}
+
+// Add indexes one file. Files are identified by the order they were added.
+func (ix *Index) Add(path, content string) {
+	d := document{freqs: make(map[string]int)}
+	for _, t := range Terms(path) {
+		d.freqs[t] += pathWeight
+		d.length += pathWeight
+	}
+	for _, t := range Terms(content) {
+		d.freqs[t]++
+		d.length++
+	}
+	for t := range d.freqs {
+		ix.df[t]++
+	}
+	ix.totalLen += d.length
+	ix.docs = append(ix.docs, d)
+}
+
+// Scores returns the BM25 score of every indexed file for query, in the
+// order the files were added. Files sharing no term with query score 0.
+func (ix *Index) Scores(query string) []float64 {
+	scores := make([]float64, len(ix.docs))
+	if len(ix.docs) == 0 {
+		return scores
+	}
+	n := float64(len(ix.docs))
+	avgLen := float64(ix.totalLen) / n
+	if avgLen == 0 {
+		avgLen = 1
+	}
+	seen := make(map[string]bool)
+	for _, t := range Terms(query) {
+		if seen[t] || ix.df[t] == 0 {
+			continue
+		}
+		seen[t] = true
+		df := float64(ix.df[t])
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for i, d := range ix.docs {
+			tf := float64(d.freqs[t])
+			if tf == 0 {
+				continue
+			}
+			scores[i] += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(d.length)/avgLen))
+		}
+	}
+	return scores
+}
+
+// Terms splits text into lower-case search terms. Identifiers are indexed
+// whole and by their camelCase and snake_case parts, so "retryPayment"
+// yields "retrypayment", "retry" and "payment".
+func Terms(text string) []string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		parts := splitIdentifier(word)
+		if len(parts) > 1 {
+			if t := normalize(strings.ReplaceAll(word, "_", "")); t != "" {
+				terms = append(terms, t)
+			}
+		}
+		for _, p := range parts {
+			if t := normalize(p); t != "" {
+				terms = append(terms, t)
+			}
+		}
+	}
+	return terms
+}
+
+// splitIdentifier breaks an identifier at underscores, lower-to-upper case
+// changes and the end of acronyms ("HTTPServer" -> "HTTP", "Server").
+func splitIdentifier(word string) []string {
+	var parts []string
+	runes := []rune(word)
+	start := 0
+	flush := func(end int) {
+		if end > start {
+			parts = append(parts, string(runes[start:end]))
+		}
+		start = end
+	}
+	for i, r := range runes {
+		switch {
+		case r == '_':
+			flush(i)
+			start = i + 1
+		case i > start && unicode.IsUpper(r):
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush(i)
+			}
+		}
+	}
+	flush(len(runes))
+	return parts
+}
+
+// normalize lower-cases a term and strips simple English plurals. Terms
+// shorter than two characters are dropped.
+func normalize(t string) string {
+	t = strings.ToLower(t)
+	switch {
+	case len(t) < 2:
+		return ""
+	case len(t) > 4 && strings.HasSuffix(t, "ies"):
+		return t[:len(t)-3] + "y"
+	case len(t) > 3 && strings.HasSuffix(t, "s") && !strings.HasSuffix(t, "ss"):
+		return t[:len(t)-1]
+	}
+	return t
+}
@@ -0,0 +1,110 @@
+package rank
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSplitIdentifier(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"retryPayment", []string{"retry", "Payment"}},
+		{"RetryPayment", []string{"Retry", "Payment"}},
+		{"HTTPServer", []string{"HTTP", "Server"}},
+		{"parseHTTPRequest", []string{"parse", "HTTP", "Request"}},
+		{"userID", []string{"user", "ID"}},
+		{"snake_case_name", []string{"snake", "case", "name"}},
+		{"__init__", []string{"init"}},
+		{"utf8Decode", []string{"utf8", "Decode"}},
+		{"HTTP", []string{"HTTP"}},
+		{"lower", []string{"lower"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := splitIdentifier(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitIdentifier(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"retryPayment()", []string{"retrypayment", "retry", "payment"}},
+		{"max_retries = 3", []string{"maxretry", "max", "retry"}},
+		{"internal/scanner/paths.go", []string{"internal", "scanner", "path", "go"}},
+		{"class Address", []string{"class", "address"}},
+		{"a + b", nil},
+	}
+	for _, tt := range tests {
+		if got := Terms(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Terms(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestScoresOrdering(t *testing.T) {
+	files := []struct{ path, content string }{
+		{"README.md", "RepoGo packs repositories. Payments are not involved."},
+		{"internal/billing/retry.go", "package billing\n\nfunc retryPayment(p Payment) error { return charge(p) }\n"},
+		{"internal/billing/invoice.go", "package billing\n\n// Invoice lists a payment.\ntype Invoice struct{ Payment Payment }\n"},
+		{"internal/scanner/scanner.go", "package scanner\n\nfunc CollectFiles() {}\n"},
+	}
+	ix := NewIndex()
+	for _, f := range files {
+		ix.Add(f.path, f.content)
+	}
+
+	tests := []struct {
+		query string
+		order []string // files with a positive score, most relevant first
+	}{
+		{"retry payment", []string{"internal/billing/retry.go", "internal/billing/invoice.go", "README.md"}},
+		{"retryPayment", []string{"internal/billing/retry.go", "internal/billing/invoice.go", "README.md"}},
+		{"invoice", []string{"internal/billing/invoice.go"}},
+		{"scanner", []string{"internal/scanner/scanner.go"}},
+		{"unrelated words", nil},
+	}
+	for _, tt := range tests {
+		scores := ix.Scores(tt.query)
+		var order []string
+		idx := make([]int, 0, len(files))
+		for i := range files {
+			if scores[i] > 0 {
+				idx = append(idx, i)
+			}
+		}
+		sort.SliceStable(idx, func(a, b int) bool { return scores[idx[a]] > scores[idx[b]] })
+		for _, i := range idx {
+			order = append(order, files[i].path)
+		}
+		if !reflect.DeepEqual(order, tt.order) {
+			t.Errorf("Scores(%q) order = %q, want %q (scores %v)", tt.query, order, tt.order, scores)
+		}
+	}
+}
+
+func TestScoresPathWeight(t *testing.T) {
+	ix := NewIndex()
+	ix.Add("docs/config.md", "see the loader")
+	ix.Add("loader.go", "package config")
+	ix.Add("other.go", "package other")
+	scores := ix.Scores("config")
+	if scores[0] <= scores[1] {
+		t.Errorf("a path match scored %v, not above a content match %v", scores[0], scores[1])
+	}
+	if scores[2] != 0 {
+		t.Errorf("an unrelated file scored %v, want 0", scores[2])
+	}
+}
+
+func TestScoresEmptyIndex(t *testing.T) {
+	if got := NewIndex().Scores("anything"); len(got) != 0 {
+		t.Errorf("Scores on an empty index = %v, want none", got)
+	}
+}
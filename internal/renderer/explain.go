@@ -0,0 +1,52 @@
+// Package renderer provides output rendering functionality for different formats.
+package renderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/models"
+)
+
+// RenderExplainMarkdown renders the decisions of explain mode as a table,
+// followed by the number of paths per decision.
+func RenderExplainMarkdown(w io.Writer, e models.Explanation) {
+	fmt.Fprint(w, "# RepoGo Explain\n\n")
+	fmt.Fprint(w, "## File System Location\n\n")
+	fmt.Fprint(w, e.Location, "\n\n")
+
+	fmt.Fprintln(w, "## Decisions")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Path | Decision | Rule | Detail |")
+	fmt.Fprintln(w, "|------|----------|------|--------|")
+	counts := map[string]int{}
+	for _, d := range e.Decisions {
+		p := d.Path
+		if d.IsDir {
+			p += "/"
+		}
+		counts[d.Decision]++
+		fmt.Fprintf(w, "| %s | %s | %s | %s |\n", cell(p), d.Decision, d.Rule, cell(d.Detail))
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "## Summary")
+	for _, decision := range []string{models.DecisionIncluded, models.DecisionStubbed, models.DecisionOmitted, models.DecisionExcluded} {
+		fmt.Fprintf(w, "- %s: %d path(s)\n", strings.ToUpper(decision[:1])+decision[1:], counts[decision])
+	}
+}
+
+// RenderExplainJSON renders the decisions of explain mode in JSON format
+// with indentation.
+func RenderExplainJSON(w io.Writer, e models.Explanation) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e)
+}
+
+// cell escapes s for use in a Markdown table cell.
+func cell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -4,43 +4,91 @@ package renderer
 import (
 	"fmt"
 	"io"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/AndersonTsaiTW/RepoGo/internal/models"
 )
 
 // RenderMarkdown renders the output document in Markdown format.
 func RenderMarkdown(w io.Writer, doc models.OutputDoc) {
-	fmt.Fprintln(w, "# Repository Context\n")
-	fmt.Fprintln(w, "## File System Location\n")
-	fmt.Fprintln(w, doc.Location, "\n")
+	fmt.Fprint(w, "# Repository Context\n\n")
+	fmt.Fprint(w, "## File System Location\n\n")
+	fmt.Fprint(w, doc.Location, "\n\n")
 
-	fmt.Fprintln(w, "## Git Info\n")
+	fmt.Fprint(w, "## Git Info\n\n")
 	if doc.Git == nil {
-		fmt.Fprintln(w, "- Not a git repository\n")
+		fmt.Fprint(w, "- Not a git repository\n\n")
 	} else {
-		fmt.Fprintf(w, "- Commit: %s\n", doc.Git.Commit)
-		fmt.Fprintf(w, "- Branch: %s\n", doc.Git.Branch)
-		fmt.Fprintf(w, "- Author: %s\n", doc.Git.Author)
-		fmt.Fprintf(w, "- Date: %s\n\n", doc.Git.Date)
+		renderGitInfo(w, doc.Git)
+	}
+	if len(doc.Log) > 0 {
+		renderLog(w, doc.Log)
 	}
 
 	fmt.Fprintln(w, "## Structure")
-	fmt.Fprintln(w, doc.Structure, "\n")
+	fmt.Fprint(w, doc.Structure, "\n\n")
+	if doc.ImportGraph != "" {
+		fmt.Fprintf(w, "### Import Graph\n\n```\n%s```\n\n", doc.ImportGraph)
+	}
+
+	if doc.Dependencies != nil {
+		renderDependencies(w, doc.Dependencies)
+	}
 
-	fmt.Fprintln(w, "## File Contents\n")
+	if doc.Diff != "" {
+		fmt.Fprintln(w, "## Diff")
+		fmt.Fprintf(w, "```diff\n%s\n```\n\n", doc.Diff)
+	}
+
+	fmt.Fprint(w, "## File Contents\n\n")
 	for _, f := range doc.Files {
-		fmt.Fprintf(w, "### File: %s\n", f.Path)
+		if doc.Query != "" {
+			fmt.Fprintf(w, "### File: %s (relevance %.3f)\n", f.Path, f.Score)
+		} else {
+			fmt.Fprintf(w, "### File: %s\n", f.Path)
+		}
 		if f.ReadErrorMessage != "" && f.Content == "" && !f.IsBinary {
 			fmt.Fprintf(w, "_Error: %s_\n\n", f.ReadErrorMessage)
 			continue
 		}
+		if h := f.History; h != nil {
+			fmt.Fprintf(w, "_Last changed in `%s` on %s by %s; %d commit(s) by %d author(s)._\n\n",
+				shortHash(h.LastCommit), h.LastDate, h.LastAuthor, h.Commits, h.Authors)
+		}
+		if f.Omitted != "" {
+			fmt.Fprintf(w, "_Omitted: %s._\n\n", f.Omitted)
+			continue
+		}
+		if f.Symlink != "" {
+			fmt.Fprintf(w, "_Symbolic link to `%s`._\n\n", f.Symlink)
+			continue
+		}
+		if d := f.Duplicate; d != nil {
+			if d.Diff == "" {
+				fmt.Fprintf(w, "_Identical to `%s`._\n\n", d.Of)
+			} else {
+				fmt.Fprintf(w, "_Nearly identical to `%s` (%.0f%% of lines in common); differences:_\n\n```diff\n%s```\n\n",
+					d.Of, d.Similarity*100, d.Diff)
+			}
+			continue
+		}
 		if f.IsBinary {
 			fmt.Fprintf(w, "_Binary file (size: %d bytes) — metadata only._\n\n", f.Size)
 			continue
 		}
-		fmt.Fprintf(w, "```%s\n%s\n```\n\n", f.LanguageHint, f.Content)
+		if len(f.Snippets) > 0 {
+			renderSnippets(w, f)
+			continue
+		}
+		if len(f.LineRanges) > 0 {
+			fmt.Fprintf(w, "```%s\n%s```\n\n", f.LanguageHint, NumberLines(f.Content, f.LineRanges))
+		} else {
+			fmt.Fprintf(w, "```%s\n%s\n```\n\n", f.LanguageHint, f.Content)
+		}
 		if f.Truncated {
-			fmt.Fprintln(w, "_[truncated]_\n")
+			fmt.Fprint(w, "_[truncated]_\n\n")
 		}
 		if f.ReadErrorMessage != "" {
 			fmt.Fprintf(w, "_Note: %s_\n\n", f.ReadErrorMessage)
@@ -51,10 +99,167 @@ func RenderMarkdown(w io.Writer, doc models.OutputDoc) {
 	fmt.Fprintf(w, "- Total files: %d\n", doc.Summary.TotalFiles)
 	fmt.Fprintf(w, "- Total lines: %d\n", doc.Summary.TotalLines)
 	fmt.Fprintf(w, "- Estimated tokens: %d\n", doc.Summary.EstimatedTokens)
+	if doc.Query != "" {
+		fmt.Fprintf(w, "- Ordered by relevance to: %q\n", doc.Query)
+	}
 	if doc.Summary.SkippedByLimit > 0 {
 		fmt.Fprintf(w, "- Skipped due to token limit: %d file(s)\n", doc.Summary.SkippedByLimit)
 	}
 	if doc.Summary.BinaryFilesCount > 0 {
 		fmt.Fprintf(w, "- Binary files detected: %d\n", doc.Summary.BinaryFilesCount)
 	}
+	if doc.Summary.CompressSavedTokens > 0 {
+		fmt.Fprintf(w, "- Compression saved ~%d tokens\n", doc.Summary.CompressSavedTokens)
+	}
+	if doc.Summary.Deduplicated > 0 {
+		fmt.Fprintf(w, "- Deduplicated: %d file(s), saving ~%d tokens\n", doc.Summary.Deduplicated, doc.Summary.DedupeSavedTokens)
+	}
+	for _, kind := range sortedKeys(doc.Summary.Skipped) {
+		fmt.Fprintf(w, "- Skipped as %s: %d file(s)\n", kind, doc.Summary.Skipped[kind])
+	}
+	for _, reason := range sortedKeys(doc.Summary.Excluded) {
+		fmt.Fprintf(w, "- Excluded as %s: %d path(s)\n", reason, doc.Summary.Excluded[reason])
+	}
+
+	if doc.Stats != nil {
+		fmt.Fprint(w, "\n## Statistics\n\n")
+		renderStatsTables(w, doc.Stats, "##")
+	}
+}
+
+// NumberLines prefixes each line of content with its line number in the
+// original file, taken from ranges, in the style of the snippet output.
+// The result ends with a newline.
+func NumberLines(content string, ranges []models.LineRange) string {
+	width := len(strconv.Itoa(ranges[len(ranges)-1].End))
+	var b strings.Builder
+	r, n := 0, ranges[0].Start
+	for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		fmt.Fprintf(&b, "%*d | %s\n", width, n, line)
+		if n++; n > ranges[r].End && r+1 < len(ranges) {
+			r++
+			n = ranges[r].Start
+		}
+	}
+	return b.String()
+}
+
+// renderSnippets writes each snippet of f as its own code block, with every
+// line prefixed by its line number in the file.
+func renderSnippets(w io.Writer, f models.FileEntry) {
+	last := f.Snippets[len(f.Snippets)-1].EndLine
+	width := len(strconv.Itoa(last))
+	for _, s := range f.Snippets {
+		fmt.Fprintf(w, "_Lines %d-%d_\n\n```%s\n", s.StartLine, s.EndLine, f.LanguageHint)
+		for i, line := range strings.Split(s.Content, "\n") {
+			fmt.Fprintf(w, "%*d | %s\n", width, s.StartLine+i, line)
+		}
+		fmt.Fprint(w, "```\n\n")
+	}
+}
+
+// renderDependencies lists the direct dependencies of each manifest and
+// the package count of each lock file.
+func renderDependencies(w io.Writer, d *models.Dependencies) {
+	fmt.Fprint(w, "## Dependencies\n\n")
+	for _, m := range d.Manifests {
+		fmt.Fprintf(w, "### %s (%s", m.Path, m.Ecosystem)
+		if m.Name != "" {
+			fmt.Fprintf(w, ": %s", m.Name)
+		}
+		fmt.Fprint(w, ")\n\n")
+		if len(m.Dependencies) == 0 {
+			fmt.Fprint(w, "- No dependencies\n\n")
+			continue
+		}
+		for _, dep := range m.Dependencies {
+			fmt.Fprintf(w, "- %s", dep.Name)
+			if dep.Version != "" {
+				fmt.Fprintf(w, " %s", dep.Version)
+			}
+			if dep.Scope != "" {
+				fmt.Fprintf(w, " (%s)", dep.Scope)
+			}
+			fmt.Fprint(w, "\n")
+		}
+		fmt.Fprint(w, "\n")
+	}
+	if len(d.LockFiles) > 0 {
+		fmt.Fprint(w, "### Lock Files\n\n")
+		for _, l := range d.LockFiles {
+			fmt.Fprintf(w, "- %s (%s): %d packages\n", l.Path, l.Ecosystem, l.Packages)
+		}
+		fmt.Fprint(w, "\n")
+	}
+}
+
+// renderGitInfo lists the repository metadata as bullet points, with the
+// information of each submodule nested below its entry.
+func renderGitInfo(w io.Writer, gi *models.GitInfo) {
+	writeGitInfo(w, gi, "")
+	fmt.Fprintln(w)
+}
+
+func writeGitInfo(w io.Writer, gi *models.GitInfo, indent string) {
+	fmt.Fprintf(w, "%s- Commit: %s\n", indent, gi.Commit)
+	if gi.Detached {
+		fmt.Fprintf(w, "%s- Branch: (detached HEAD at %s)\n", indent, shortHash(gi.Commit))
+	} else {
+		fmt.Fprintf(w, "%s- Branch: %s\n", indent, gi.Branch)
+	}
+	if gi.Upstream != "" {
+		fmt.Fprintf(w, "%s- Upstream: %s (ahead %d, behind %d)\n", indent, gi.Upstream, gi.Ahead, gi.Behind)
+	}
+	if len(gi.Tags) > 0 {
+		fmt.Fprintf(w, "%s- Tags: %s\n", indent, strings.Join(gi.Tags, ", "))
+	}
+	fmt.Fprintf(w, "%s- Author: %s\n", indent, gi.Author)
+	fmt.Fprintf(w, "%s- Date: %s\n", indent, gi.Date)
+	for _, r := range gi.Remotes {
+		fmt.Fprintf(w, "%s- Remote %s: %s\n", indent, r.Name, r.URL)
+	}
+	for _, sm := range gi.Submodules {
+		status := ""
+		if sm.Status != "" {
+			status = " (" + sm.Status + ")"
+		}
+		fmt.Fprintf(w, "%s- Submodule %s: pinned at %s%s\n", indent, sm.Path, shortHash(sm.Commit), status)
+		if sm.Git != nil {
+			writeGitInfo(w, sm.Git, indent+"  ")
+		}
+	}
+}
+
+// sortedKeys returns the keys of m in ascending order.
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// shortHash abbreviates a commit hash to 12 characters.
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+// renderLog lists recent commits, newest first, with their file stats.
+func renderLog(w io.Writer, commits []models.Commit) {
+	fmt.Fprint(w, "## Recent Commits\n\n")
+	for _, c := range commits {
+		fmt.Fprintf(w, "- `%s` %s %s: %s\n", shortHash(c.Hash), c.Date, c.Author, c.Subject)
+		for _, f := range c.Files {
+			if f.Binary {
+				fmt.Fprintf(w, "  - %s (binary)\n", f.Path)
+			} else {
+				fmt.Fprintf(w, "  - %s (+%d -%d)\n", f.Path, f.Added, f.Deleted)
+			}
+		}
+	}
+	fmt.Fprint(w, "\n")
 }
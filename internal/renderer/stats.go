@@ -0,0 +1,83 @@
+// Package renderer provides output rendering functionality for different formats.
+package renderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/models"
+)
+
+// RenderStatsMarkdown renders a standalone statistics report in Markdown format.
+func RenderStatsMarkdown(w io.Writer, doc models.OutputDoc) {
+	fmt.Fprint(w, "# Repository Statistics\n\n")
+	fmt.Fprint(w, "## File System Location\n\n")
+	fmt.Fprint(w, doc.Location, "\n\n")
+
+	fmt.Fprintln(w, "## Summary")
+	fmt.Fprintf(w, "- Total files: %d\n", doc.Summary.TotalFiles)
+	fmt.Fprintf(w, "- Total lines: %d\n", doc.Summary.TotalLines)
+	fmt.Fprintf(w, "- Estimated tokens: %d\n\n", doc.Summary.EstimatedTokens)
+
+	if doc.Stats != nil {
+		renderStatsTables(w, doc.Stats, "#")
+	}
+}
+
+// RenderStatsJSON renders the location, summary and statistics of the
+// document in JSON format with indentation.
+func RenderStatsJSON(w io.Writer, doc models.OutputDoc) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Location string         `json:"location"`
+		Summary  models.Summary `json:"summary"`
+		Stats    *models.Stats  `json:"stats"`
+	}{doc.Location, doc.Summary, doc.Stats})
+}
+
+// renderStatsTables writes the statistics tables using headings one level
+// below the given prefix.
+func renderStatsTables(w io.Writer, s *models.Stats, level string) {
+	fmt.Fprintf(w, "%s# By Language\n\n", level)
+	renderGroupTable(w, "Language", s.ByLanguage)
+
+	fmt.Fprintf(w, "%s# By Directory\n\n", level)
+	renderGroupTable(w, "Directory", s.ByDirectory)
+
+	if len(s.LargestFiles) > 0 {
+		fmt.Fprintf(w, "%s# Largest Files\n\n", level)
+		fmt.Fprintln(w, "| File | Language | Bytes | Lines | Tokens |")
+		fmt.Fprintln(w, "|------|----------|------:|------:|-------:|")
+		for _, f := range s.LargestFiles {
+			fmt.Fprintf(w, "| %s | %s | %d | %d | %d |\n", f.Path, f.Language, f.Bytes, f.Lines, f.Tokens)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(s.HeaviestDirectories) > 0 {
+		fmt.Fprintf(w, "%s# Most Token-Heavy Directories\n\n", level)
+		renderGroupTable(w, "Directory", s.HeaviestDirectories)
+	}
+
+	if len(s.Hotspots) > 0 {
+		fmt.Fprintf(w, "%s# Hotspots\n\n", level)
+		fmt.Fprintln(w, "| File | Commits | Authors | Lines | Tokens |")
+		fmt.Fprintln(w, "|------|--------:|--------:|------:|-------:|")
+		for _, f := range s.Hotspots {
+			fmt.Fprintf(w, "| %s | %d | %d | %d | %d |\n", f.Path, f.Commits, f.Authors, f.Lines, f.Tokens)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func renderGroupTable(w io.Writer, label string, groups []models.GroupStats) {
+	fmt.Fprintf(w, "| %s | Files | Code | Comment | Blank | Bytes | Tokens |\n", label)
+	fmt.Fprintln(w, "|---|------:|-----:|--------:|------:|------:|-------:|")
+	for _, g := range groups {
+		fmt.Fprintf(w, "| %s | %d | %d | %d | %d | %d | %d |\n",
+			g.Name, g.Files, g.CodeLines, g.CommentLines, g.BlankLines, g.Bytes, g.Tokens)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,16 @@
+// Package renderer provides output rendering functionality for different formats.
+package renderer
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/models"
+)
+
+// RenderTreeJSON renders the directory tree in JSON format with indentation.
+func RenderTreeJSON(w io.Writer, root *models.TreeNode) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(root)
+}
@@ -0,0 +1,9 @@
+//go:build !unix && !windows
+
+package scanner
+
+// fileID is unavailable on this platform; cycle detection falls back to
+// comparing resolved paths.
+func fileID(path string) (fileKey, bool) {
+	return fileKey{}, false
+}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package scanner
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID identifies a file by device and inode, following symlinks.
+func fileID(path string) (fileKey, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileKey{}, false
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileKey{}, false
+	}
+	return fileKey{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
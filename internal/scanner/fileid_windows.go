@@ -0,0 +1,29 @@
+//go:build windows
+
+package scanner
+
+import "syscall"
+
+// fileID identifies a file by volume serial number and file index,
+// following symlinks and junctions.
+func fileID(path string) (fileKey, bool) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return fileKey{}, false
+	}
+	// FILE_FLAG_BACKUP_SEMANTICS is required to open directories.
+	h, err := syscall.CreateFile(p, 0, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return fileKey{}, false
+	}
+	defer syscall.CloseHandle(h)
+	var info syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(h, &info); err != nil {
+		return fileKey{}, false
+	}
+	return fileKey{
+		dev: uint64(info.VolumeSerialNumber),
+		ino: uint64(info.FileIndexHigh)<<32 | uint64(info.FileIndexLow),
+	}, true
+}
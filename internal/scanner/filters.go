@@ -1,7 +1,12 @@
 // Package scanner provides file system scanning and filtering functionality.
 package scanner
 
-import "strings"
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
 
 // SplitList splits a comma-separated string into a slice of trimmed strings.
 // Returns nil if the input is empty or contains only whitespace.
@@ -19,3 +24,37 @@ func SplitList(s string) []string {
 	}
 	return out
 }
+
+// exclusion returns why opts' depth, size and modification time limits
+// exclude the entry at rel, or "" to keep it. info is only called for files,
+// and only when a size or time limit is set.
+func (o *Options) exclusion(rel string, isDir bool, info func() (fs.FileInfo, error)) string {
+	if o.MaxDepth > 0 {
+		// A directory at the limit is pruned, since all it holds lies deeper.
+		depth := strings.Count(filepath.ToSlash(rel), "/") + 1
+		if depth > o.MaxDepth || (isDir && depth == o.MaxDepth) {
+			return fmt.Sprintf("deeper than %d level(s)", o.MaxDepth)
+		}
+	}
+	if isDir || (o.MinSize == 0 && o.MaxSize == 0 && o.NewerThan.IsZero() && o.OlderThan.IsZero()) {
+		return ""
+	}
+	fi, err := info()
+	if err != nil {
+		return "" // reported when the file is read
+	}
+	switch {
+	case o.MinSize > 0 && fi.Size() < o.MinSize:
+		return fmt.Sprintf("smaller than %d bytes", o.MinSize)
+	case o.MaxSize > 0 && fi.Size() > o.MaxSize:
+		return fmt.Sprintf("larger than %d bytes", o.MaxSize)
+	case !o.NewerThan.IsZero() && !fi.ModTime().After(o.NewerThan):
+		return "not modified since " + o.NewerThan.Format(timeLayout)
+	case !o.OlderThan.IsZero() && fi.ModTime().After(o.OlderThan):
+		return "modified since " + o.OlderThan.Format(timeLayout)
+	}
+	return ""
+}
+
+// timeLayout formats the time limits in exclusion reasons.
+const timeLayout = "2006-01-02 15:04"
@@ -0,0 +1,62 @@
+package scanner
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrOutsideRoot is returned by Resolve for paths that leave the root.
+var ErrOutsideRoot = errors.New("path is outside the allowed root")
+
+// Resolve maps an untrusted, slash-separated relative path onto root, which
+// must be absolute and free of symlinks. Absolute paths, ".." components and
+// symlinks leading outside root are rejected with ErrOutsideRoot.
+func Resolve(root, p string) (string, error) {
+	if p == "" || strings.ContainsRune(p, 0) {
+		return "", errors.New("invalid path")
+	}
+	if path.IsAbs(p) || filepath.IsAbs(p) || filepath.VolumeName(p) != "" {
+		return "", ErrOutsideRoot
+	}
+	slashed := filepath.ToSlash(p)
+	for _, part := range strings.Split(slashed, "/") {
+		if part == ".." {
+			return "", ErrOutsideRoot
+		}
+	}
+	full := filepath.Join(root, filepath.FromSlash(path.Clean(slashed)))
+	real, err := filepath.EvalSymlinks(full)
+	if err != nil {
+		return "", err
+	}
+	if !Within(root, real) {
+		return "", ErrOutsideRoot
+	}
+	return full, nil
+}
+
+// Within reports whether p is root or lies below it.
+func Within(root, p string) bool {
+	rel, err := filepath.Rel(root, p)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// ContainedSymlinks returns a filter that rejects symlinks under root whose
+// target lies outside it, for use as a repogo.Filter.
+func ContainedSymlinks(root string) func(rel string, isDir bool) bool {
+	return func(rel string, isDir bool) bool {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if info, err := os.Lstat(p); err != nil || info.Mode()&fs.ModeSymlink == 0 {
+			return true
+		}
+		real, err := filepath.EvalSymlinks(p)
+		return err == nil && Within(root, real)
+	}
+}
@@ -0,0 +1,129 @@
+// Package scanner provides file system scanning and filtering functionality.
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// PresetAuto selects every preset whose markers exist in the root.
+const PresetAuto = "auto"
+
+// Preset is a curated set of exclude rules for one ecosystem. Markers are
+// root-level files whose presence selects the preset under PresetAuto.
+type Preset struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Markers     []string `json:"markers,omitempty"`
+	Excludes    []string `json:"excludes"`
+}
+
+// commonPreset is added whenever any preset is selected: media, fonts and
+// archives that are never useful as text context.
+var commonPreset = Preset{
+	Name:        "common",
+	Description: "Images, fonts, media and archives (added by every preset)",
+	Excludes: []string{
+		"*.png", "*.jpg", "*.jpeg", "*.gif", "*.bmp", "*.ico", "*.webp", "*.svg",
+		"*.woff", "*.woff2", "*.ttf", "*.otf", "*.eot",
+		"*.mp3", "*.mp4", "*.mov", "*.wav", "*.pdf",
+		"*.zip", "*.tar", "*.gz", "*.tgz", "*.7z",
+		".DS_Store",
+	},
+}
+
+var presets = []Preset{
+	commonPreset,
+	{
+		Name:        "go",
+		Description: "Go modules: vendored code and checksum files",
+		Markers:     []string{"go.mod", "go.work"},
+		Excludes:    []string{"vendor", "go.sum", "go.work.sum", "*.test", "*.prof"},
+	},
+	{
+		Name:        "node",
+		Description: "JavaScript/TypeScript: dependencies, build output, minified files and lock files",
+		Markers:     []string{"package.json"},
+		Excludes: []string{
+			"node_modules", "dist", "build", "coverage", ".next", ".nuxt", ".turbo", ".cache", ".yarn",
+			"*.min.js", "*.min.css", "*.map",
+			"package-lock.json", "yarn.lock", "pnpm-lock.yaml", "bun.lockb",
+		},
+	},
+	{
+		Name:        "python",
+		Description: "Python: bytecode, virtualenvs, tool caches, build output and lock files",
+		Markers:     []string{"pyproject.toml", "setup.py", "setup.cfg", "requirements.txt", "Pipfile"},
+		Excludes: []string{
+			"__pycache__", "*.pyc", "*.pyo", ".venv", "venv", ".tox", ".nox",
+			".mypy_cache", ".pytest_cache", ".ruff_cache", "*.egg-info", "dist", "build",
+			"poetry.lock", "Pipfile.lock", "uv.lock",
+		},
+	},
+	{
+		Name:        "java",
+		Description: "Java/Kotlin: Maven and Gradle build output and compiled classes",
+		Markers:     []string{"pom.xml", "build.gradle", "build.gradle.kts", "settings.gradle", "settings.gradle.kts"},
+		Excludes:    []string{"target", "build", "out", ".gradle", "*.class", "*.jar", "*.war", "*.ear"},
+	},
+	{
+		Name:        "rust",
+		Description: "Rust: Cargo build output and lock file",
+		Markers:     []string{"Cargo.toml"},
+		Excludes:    []string{"target", "Cargo.lock"},
+	},
+}
+
+// Presets returns the built-in presets.
+func Presets() []Preset {
+	return presets
+}
+
+// DetectPresets returns the names of the presets whose markers exist in root.
+func DetectPresets(root string) []string {
+	var names []string
+	for _, p := range presets {
+		for _, m := range p.Markers {
+			if _, err := os.Stat(filepath.Join(root, m)); err == nil {
+				names = append(names, p.Name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// ResolvePresets expands names, which may include PresetAuto, into the
+// selected presets for root, the common preset first. It returns nil when
+// names is empty or auto-detection finds nothing.
+func ResolvePresets(root string, names []string) ([]Preset, error) {
+	selected := map[string]bool{}
+	for _, name := range names {
+		if name == PresetAuto {
+			for _, d := range DetectPresets(root) {
+				selected[d] = true
+			}
+			continue
+		}
+		found := false
+		for _, p := range presets {
+			if p.Name == name {
+				selected[name], found = true, true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown preset %q (run \"repogo presets\" for the list)", name)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, nil
+	}
+	out := []Preset{commonPreset}
+	for _, p := range presets {
+		if selected[p.Name] && p.Name != commonPreset.Name {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
@@ -2,18 +2,125 @@
 package scanner
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"time"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/models"
+)
+
+// Symlink policies for Options.Symlinks.
+const (
+	SymlinksFollow = "follow"
+	SymlinksSkip   = "skip"
+	SymlinksRecord = "record"
+)
+
+// Rules reported in Decision.Rule for paths the scan leaves out.
+const (
+	RuleExclude    = "exclude"    // an exclude glob
+	RulePreset     = "preset"     // an exclude of an ecosystem preset
+	RuleInclude    = "include"    // no include glob matches
+	RuleFilter     = "filter"     // rejected by a filter behind Options.Reject
+	RuleLimit      = "limit"      // a depth, size or modification time limit
+	RuleSymlink    = "symlink"    // the symlink policy, or a broken link
+	RuleParent     = "parent"     // inside an excluded directory
+	RuleUnreadable = "unreadable" // an input path that cannot be read
 )
 
-// CollectFiles scans the filesystem and collects files based on include/exclude patterns.
-// Returns a list of file paths and a string representation of the directory structure.
-func CollectFiles(root string, inputs, includes, excludes []string) ([]string, string) {
+// errSymlinkCycle is reported for linked directories that contain themselves.
+var errSymlinkCycle = errors.New("symlink cycle")
+
+// Options configures CollectFiles.
+type Options struct {
+	Includes []string
+	Excludes []string
+	// Presets names built-in ecosystem presets (see Presets) whose excludes
+	// apply in addition to Excludes; PresetAuto detects them from root.
+	Presets []string
+	// Reject, when set, is consulted after the include/exclude globs and
+	// returns the rule and detail leaving a path out, or an empty rule to
+	// keep it. rel is slash-separated and relative to the root; rejecting a
+	// directory skips everything below it.
+	Reject func(rel string, isDir bool) (rule, detail string)
+	// OnWarning receives errors for individual paths that do not stop the
+	// walk, such as unreadable directories. Nil ignores them.
+	OnWarning func(path string, err error)
+	// Symlinks selects how symbolic links met during the walk are treated:
+	// SymlinksFollow (the default) reads linked files and traverses linked
+	// directories, skipping cycles; SymlinksSkip ignores links; and
+	// SymlinksRecord passes each link to OnSymlink instead of collecting it,
+	// applying the limits below to the link itself. Links whose target lies
+	// outside root are refused with a warning unless SymlinksOutside is set.
+	Symlinks        string
+	SymlinksOutside bool
+	OnSymlink       func(path, target string)
+	// NewerThan and OlderThan keep only files last modified after, or not
+	// after, the given times; MinSize and MaxSize only files of at least,
+	// or at most, that many bytes. MaxDepth limits the directory levels
+	// below root: 1 keeps only the files directly in it. Zero values set no
+	// limit. These apply after the globs and Filter, and each path they
+	// exclude is passed to OnExclude with the reason.
+	NewerThan time.Time
+	OlderThan time.Time
+	MinSize   int64
+	MaxSize   int64
+	MaxDepth  int
+	OnExclude func(path, reason string)
+	// OnDecision, when set, receives every path the scan leaves out with
+	// the rule responsible. Excluded directories are then also walked, so
+	// that the paths they hide are reported with RuleParent.
+	OnDecision func(models.Decision)
+	// CheckParents also applies the excludes and Filter to the directories
+	// between root and each file given as an input, as a walk would, so
+	// that a listed file below an excluded directory is left out.
+	CheckParents bool
+}
+
+// fileKey identifies a directory for cycle detection: by device and inode
+// where the platform provides them, otherwise by its resolved path.
+type fileKey struct {
+	dev, ino uint64
+	path     string
+}
+
+func dirKey(p string) fileKey {
+	if id, ok := fileID(p); ok {
+		return id
+	}
+	real, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		real = p
+	}
+	return fileKey{path: real}
+}
+
+// CollectFiles scans the filesystem and collects files below root based on
+// include/exclude patterns. Excludes prune whole directories, while includes
+// only apply to files. It returns the sorted absolute paths, or an error if an
+// input cannot be read or ctx is cancelled.
+func CollectFiles(ctx context.Context, root string, inputs []string, opts Options) ([]string, error) {
+	switch opts.Symlinks {
+	case "", SymlinksFollow, SymlinksSkip, SymlinksRecord:
+	default:
+		return nil, fmt.Errorf("unknown symlink policy %q (want %s, %s or %s)", opts.Symlinks, SymlinksFollow, SymlinksSkip, SymlinksRecord)
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+	presets, err := ResolvePresets(root, opts.Presets)
+	if err != nil {
+		return nil, err
+	}
 	seen := map[string]struct{}{}
 	var files []string
 	add := func(p string) {
@@ -23,56 +130,197 @@ func CollectFiles(root string, inputs, includes, excludes []string) ([]string, s
 		}
 	}
 
-	shouldKeep := func(rel string) bool {
-		if matchAny(excludes, rel) {
+	// rejection returns the rule leaving rel out and its detail, or "" if
+	// the globs and Filter keep it.
+	rejection := func(rel string, isDir bool) (string, string) {
+		if pat, ok := matchWhich(opts.Excludes, rel); ok {
+			return RuleExclude, pat
+		}
+		for _, p := range presets {
+			if pat, ok := matchWhich(p.Excludes, rel); ok {
+				return RulePreset, p.Name + ": " + pat
+			}
+		}
+		if !isDir && len(opts.Includes) > 0 && !matchAny(opts.Includes, rel) {
+			return RuleInclude, "matches none of " + strings.Join(opts.Includes, ", ")
+		}
+		if opts.Reject != nil {
+			return opts.Reject(filepath.ToSlash(rel), isDir)
+		}
+		return "", ""
+	}
+	// exclude reports a path left out to OnDecision, together with the
+	// contents of excluded directories.
+	exclude := func(p, rel string, isDir bool, rule, detail string) {
+		if opts.OnDecision == nil {
+			return
+		}
+		opts.OnDecision(models.Decision{Path: filepath.ToSlash(rel), IsDir: isDir, Decision: models.DecisionExcluded, Rule: rule, Detail: detail})
+		if isDir {
+			explainBelow(p, root, parentDetail(rel, rule, detail), opts.OnDecision)
+		}
+	}
+	shouldKeep := func(p, rel string, isDir bool) bool {
+		rule, detail := rejection(rel, isDir)
+		if rule != "" {
+			exclude(p, rel, isDir, rule, detail)
+		}
+		return rule == ""
+	}
+	// limited applies the depth, size and time limits to a path that passed
+	// shouldKeep.
+	limited := func(p, rel string, isDir bool, info func() (fs.FileInfo, error)) bool {
+		reason := opts.exclusion(rel, isDir, info)
+		if reason == "" {
 			return false
 		}
-		if len(includes) == 0 {
-			return true
+		if opts.OnExclude != nil {
+			opts.OnExclude(p, reason)
 		}
-		return matchAny(includes, rel)
+		exclude(p, rel, isDir, RuleLimit, reason)
+		return true
+	}
+	warn := func(p string, err error) {
+		if opts.OnWarning != nil {
+			opts.OnWarning(p, err)
+		}
+	}
+	// skipLink reports a symbolic link that is not followed.
+	skipLink := func(p, rel string, err error) {
+		if err != nil {
+			warn(p, err)
+		}
+		detail := "-symlinks " + SymlinksSkip
+		if err != nil {
+			detail = err.Error()
+		}
+		exclude(p, rel, false, RuleSymlink, detail)
 	}
 
 	for _, in := range inputs {
 		ap, err := filepath.Abs(in)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "skip %s: %v\n", in, err)
+			warn(in, err)
 			continue
 		}
 		info, err := os.Stat(ap)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "skip %s: %v\n", in, err)
+			// Skip it, as a walk skips unreadable entries.
+			warn(ap, err)
+			rel, _ := filepath.Rel(root, ap)
+			exclude(ap, rel, false, RuleUnreadable, err.Error())
 			continue
 		}
-		if info.IsDir() {
-			filepath.WalkDir(ap, func(p string, d fs.DirEntry, err error) error {
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "walk error %s: %v\n", p, err)
-					return nil
+		if !info.IsDir() {
+			rel, _ := filepath.Rel(root, ap)
+			if opts.CheckParents {
+				if dir, rule, detail := rejectedParent(rel, rejection); rule != "" {
+					exclude(ap, rel, false, RuleParent, parentDetail(dir, rule, detail))
+					continue
 				}
-				rel, _ := filepath.Rel(root, p)
-				if rel == "." {
-					return nil
+			}
+			if shouldKeep(ap, rel, false) && !limited(ap, rel, false, func() (fs.FileInfo, error) { return info, nil }) {
+				add(ap)
+			}
+			continue
+		}
+		var walk func(dir string, ancestors []fileKey) error
+		walk = func(dir string, ancestors []fileKey) error {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				warn(dir, err) // entries read before the error are still walked
+			}
+			for _, d := range entries {
+				if err := ctx.Err(); err != nil {
+					return err
 				}
-				if !shouldKeep(rel) {
-					if d.IsDir() {
-						return fs.SkipDir
+				p := filepath.Join(dir, d.Name())
+				rel, _ := filepath.Rel(root, p)
+				isDir := d.IsDir()
+				info := d.Info
+				if d.Type()&fs.ModeSymlink != 0 {
+					switch opts.Symlinks {
+					case SymlinksSkip:
+						skipLink(p, rel, nil)
+						continue
+					case SymlinksRecord:
+						if target, err := os.Readlink(p); err != nil {
+							skipLink(p, rel, err)
+						} else if shouldKeep(p, rel, false) && !limited(p, rel, false, info) && opts.OnSymlink != nil {
+							opts.OnSymlink(p, target)
+						}
+						continue
+					}
+					real, err := filepath.EvalSymlinks(p)
+					if err != nil {
+						skipLink(p, rel, err) // dangling link
+						continue
+					}
+					if !opts.SymlinksOutside && !Within(realRoot, real) {
+						skipLink(p, rel, fmt.Errorf("link to %s: %w", real, ErrOutsideRoot))
+						continue
 					}
-					return nil
+					target, err := os.Stat(real)
+					if err != nil {
+						skipLink(p, rel, err)
+						continue
+					}
+					isDir = target.IsDir()
+					info = func() (fs.FileInfo, error) { return target, nil }
+				}
+				if !shouldKeep(p, rel, isDir) || limited(p, rel, isDir, info) {
+					continue
+				}
+				if !isDir {
+					add(p)
+					continue
+				}
+				key := dirKey(p)
+				if slices.Contains(ancestors, key) {
+					skipLink(p, rel, errSymlinkCycle)
+					continue
+				}
+				if err := walk(p, append(ancestors, key)); err != nil {
+					return err
 				}
-				add(p)
-				return nil
-			})
-		} else {
-			rel, _ := filepath.Rel(root, ap)
-			if shouldKeep(rel) {
-				add(ap)
 			}
+			return nil
+		}
+		if err := walk(ap, []fileKey{dirKey(ap)}); err != nil {
+			return nil, err
 		}
 	}
 	sort.Strings(files)
-	structure := buildTree(root, files)
-	return files, structure
+	return files, nil
+}
+
+// rejectedParent returns the outermost directory above rel, up to the root,
+// that rejection leaves out, with the rule and its detail.
+func rejectedParent(rel string, rejection func(rel string, isDir bool) (string, string)) (dir, rule, detail string) {
+	for d := filepath.Dir(rel); d != "." && d != ".." && !strings.HasPrefix(d, ".."+string(filepath.Separator)); d = filepath.Dir(d) {
+		if r, det := rejection(d, true); r != "" {
+			dir, rule, detail = d, r, det
+		}
+	}
+	return dir, rule, detail
+}
+
+// parentDetail describes the exclusion of a directory for the paths below it.
+func parentDetail(dir, rule, detail string) string {
+	return fmt.Sprintf("inside %s/ (%s %s)", filepath.ToSlash(dir), rule, detail)
+}
+
+// explainBelow reports every path below the excluded directory dir as
+// excluded by it. Symbolic links are not followed.
+func explainBelow(dir, root, detail string, onDecision func(models.Decision)) {
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == dir {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		onDecision(models.Decision{Path: filepath.ToSlash(rel), IsDir: d.IsDir(), Decision: models.DecisionExcluded, Rule: RuleParent, Detail: detail})
+		return nil
+	})
 }
 
 // ResolveRoot determines the root directory from the given input paths.
@@ -103,54 +351,26 @@ func commonBase(a, b string) string {
 	for i < len(pa) && i < len(pb) && pa[i] == pb[i] {
 		i++
 	}
-	return filepath.Join(pa[:i]...)
+	base := filepath.Join(pa[:i]...)
+	if filepath.IsAbs(a) && !filepath.IsAbs(base) {
+		// Join drops the empty element standing for the leading separator.
+		base = string(os.PathSeparator) + base
+	}
+	return base
 }
 
-func buildTree(root string, files []string) string {
-	type node struct {
-		name     string
-		children map[string]*node
-		file     bool
-	}
-	rootNode := &node{children: map[string]*node{}}
-	for _, f := range files {
-		rel, _ := filepath.Rel(root, f)
-		if rel == "." {
-			continue
-		}
-		parts := strings.Split(filepath.ToSlash(rel), "/")
-		cur := rootNode
-		for i, part := range parts {
-			if cur.children[part] == nil {
-				cur.children[part] = &node{name: part, children: map[string]*node{}}
-			}
-			cur = cur.children[part]
-			if i == len(parts)-1 {
-				cur.file = true
-			}
+// matchWhich returns the first of patterns matching rel or its base name.
+func matchWhich(patterns []string, rel string) (string, bool) {
+	rel = filepath.ToSlash(rel)
+	for _, pat := range patterns {
+		if ok, _ := path.Match(pat, rel); ok {
+			return pat, true
 		}
-	}
-	var b strings.Builder
-	var walk func(*node, int)
-	walk = func(n *node, depth int) {
-		keys := make([]string, 0, len(n.children))
-		for k := range n.children {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
-		for _, k := range keys {
-			ch := n.children[k]
-			b.WriteString(strings.Repeat("  ", depth))
-			if ch.file {
-				b.WriteString(k + "\n")
-			} else {
-				b.WriteString(k + "/\n")
-			}
-			walk(ch, depth+1)
+		if ok, _ := path.Match(pat, path.Base(rel)); ok {
+			return pat, true
 		}
 	}
-	walk(rootNode, 0)
-	return "```\n" + b.String() + "```"
+	return "", false
 }
 
 func matchAny(patterns []string, rel string) bool {
@@ -0,0 +1,124 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeTree creates the given files, with their parent directories, below dir.
+func writeTree(t *testing.T, dir string, files ...string) {
+	t.Helper()
+	for _, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(f+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// relPaths returns paths relative to root, slash-separated.
+func relPaths(t *testing.T, root string, paths []string) []string {
+	t.Helper()
+	var rels []string
+	for _, p := range paths {
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rels = append(rels, filepath.ToSlash(rel))
+	}
+	return rels
+}
+
+func TestCollectFilesSkipsMissingInputs(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, "a.go", "src/b.go")
+
+	var warned []string
+	files, err := CollectFiles(context.Background(), root,
+		[]string{filepath.Join(root, "missing.go"), filepath.Join(root, "a.go"), filepath.Join(root, "src")},
+		Options{OnWarning: func(p string, err error) { warned = append(warned, filepath.Base(p)) }})
+	if err != nil {
+		t.Fatalf("CollectFiles: %v", err)
+	}
+	if got, want := relPaths(t, root, files), []string{"a.go", "src/b.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %q, want %q", got, want)
+	}
+	if want := []string{"missing.go"}; !reflect.DeepEqual(warned, want) {
+		t.Errorf("warnings for %q, want %q", warned, want)
+	}
+}
+
+func TestResolveRoot(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, "a/x.go", "a/b/y.go", "c/z.go")
+	tests := []struct {
+		name   string
+		inputs []string
+		want   string
+	}{
+		{"directory", []string{"a", "c/z.go"}, "a"},
+		{"single file", []string{"a/b/y.go"}, "a/b"},
+		{"files in sibling directories", []string{"a/b/y.go", "c/z.go"}, "."},
+		{"missing file", []string{"a/x.go", "a/gone.go"}, "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inputs []string
+			for _, in := range tt.inputs {
+				inputs = append(inputs, filepath.Join(root, filepath.FromSlash(in)))
+			}
+			got, err := ResolveRoot(inputs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(root, filepath.FromSlash(tt.want)); got != want {
+				t.Errorf("ResolveRoot(%q) = %s, want %s", tt.inputs, got, want)
+			}
+		})
+	}
+}
+
+func TestCollectFilesSymlinks(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, "a.go", "deep/er/b.go")
+	for link, target := range map[string]string{"top.go": "a.go", "deep/er/link.go": "../er/b.go"} {
+		if err := os.Symlink(target, filepath.Join(root, filepath.FromSlash(link))); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+	}
+	tests := []struct {
+		name     string
+		opts     Options
+		files    []string
+		recorded []string
+	}{
+		{"follow by default", Options{}, []string{"a.go", "deep/er/b.go", "deep/er/link.go", "top.go"}, nil},
+		{"skip", Options{Symlinks: SymlinksSkip}, []string{"a.go", "deep/er/b.go"}, nil},
+		{"record", Options{Symlinks: SymlinksRecord}, []string{"a.go", "deep/er/b.go"}, []string{"deep/er/link.go", "top.go"}},
+		// The links are 4 and 10 bytes long.
+		{"record within limits", Options{Symlinks: SymlinksRecord, MinSize: 6}, []string{"deep/er/b.go"}, []string{"deep/er/link.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var recorded []string
+			tt.opts.OnSymlink = func(p, target string) { recorded = append(recorded, p) }
+			files, err := CollectFiles(context.Background(), root, []string{root}, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := relPaths(t, root, files); !reflect.DeepEqual(got, tt.files) {
+				t.Errorf("files = %q, want %q", got, tt.files)
+			}
+			if got := relPaths(t, root, recorded); !reflect.DeepEqual(got, tt.recorded) {
+				t.Errorf("recorded links = %q, want %q", got, tt.recorded)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Package scanner provides file system scanning and filtering functionality.
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/models"
+)
+
+// Tree styles accepted by TreeOptions.Style.
+const (
+	TreeStyleIndent  = "indent"
+	TreeStyleASCII   = "ascii"
+	TreeStyleUnicode = "unicode"
+)
+
+// TreeOptions controls how the directory structure is rendered.
+type TreeOptions struct {
+	Style    string // indent (default), ascii or unicode
+	MaxDepth int    // levels below this depth are collapsed (0 = no limit)
+	Annotate bool   // show size, line count and estimated tokens per node
+}
+
+// TreeFile is a scanned path together with the figures used to annotate it.
+type TreeFile struct {
+	Path   string // absolute path
+	IsDir  bool
+	Size   int64
+	Lines  int
+	Tokens int
+	Note   string // shown next to the entry, e.g. for a stubbed submodule
+}
+
+// BuildTree arranges files (absolute paths below root) into a tree whose
+// directory nodes aggregate the size, line and token counts of their files.
+func BuildTree(root string, files []TreeFile) *models.TreeNode {
+	rootNode := &models.TreeNode{Name: ".", Path: ".", IsDir: true}
+	index := map[string]*models.TreeNode{".": rootNode}
+	for _, f := range files {
+		rel, _ := filepath.Rel(root, f.Path)
+		if rel == "." {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		parts := strings.Split(rel, "/")
+		cur := rootNode
+		for i, part := range parts {
+			p := strings.Join(parts[:i+1], "/")
+			n, ok := index[p]
+			if !ok {
+				n = &models.TreeNode{Name: part, Path: p}
+				index[p] = n
+				cur.Children = append(cur.Children, n)
+			}
+			if i < len(parts)-1 {
+				n.IsDir = true
+			}
+			cur = n
+		}
+		cur.Note = f.Note
+		if f.IsDir {
+			cur.IsDir = true
+			continue
+		}
+		cur.Size, cur.Lines, cur.Tokens = f.Size, f.Lines, f.Tokens
+	}
+	aggregate(rootNode)
+	return rootNode
+}
+
+// aggregate sorts children by name and sums file figures into directories.
+func aggregate(n *models.TreeNode) {
+	if !n.IsDir {
+		n.Files = 1
+		return
+	}
+	sort.Slice(n.Children, func(i, j int) bool { return n.Children[i].Name < n.Children[j].Name })
+	n.Files, n.Size, n.Lines, n.Tokens = 0, 0, 0, 0
+	for _, ch := range n.Children {
+		aggregate(ch)
+		n.Files += ch.Files
+		n.Size += ch.Size
+		n.Lines += ch.Lines
+		n.Tokens += ch.Tokens
+	}
+}
+
+// RenderTree renders the children of root as plain text according to opts.
+func RenderTree(root *models.TreeNode, opts TreeOptions) string {
+	var b strings.Builder
+	var walk func(n *models.TreeNode, depth int, prefix string)
+	walk = func(n *models.TreeNode, depth int, prefix string) {
+		for i, ch := range n.Children {
+			last := i == len(n.Children)-1
+			branch, next := treeBranch(opts.Style, last)
+			b.WriteString(prefix + branch + ch.Name)
+			if ch.IsDir {
+				b.WriteString("/")
+			}
+			if ch.Note != "" {
+				b.WriteString("  (" + ch.Note + ")")
+			}
+			if opts.Annotate {
+				b.WriteString("  " + annotation(ch))
+			}
+			b.WriteString("\n")
+			if !ch.IsDir || len(ch.Children) == 0 {
+				continue
+			}
+			if opts.MaxDepth > 0 && depth+1 >= opts.MaxDepth {
+				collapsed, _ := treeBranch(opts.Style, true)
+				fmt.Fprintf(&b, "%s%s… (%d files, ~%d tokens)\n", prefix+next, collapsed, ch.Files, ch.Tokens)
+				continue
+			}
+			walk(ch, depth+1, prefix+next)
+		}
+	}
+	walk(root, 0, "")
+	return b.String()
+}
+
+// treeBranch returns the connector printed before a node and the prefix
+// inherited by its children.
+func treeBranch(style string, last bool) (string, string) {
+	switch style {
+	case TreeStyleUnicode:
+		if last {
+			return "└── ", "    "
+		}
+		return "├── ", "│   "
+	case TreeStyleASCII:
+		if last {
+			return "`-- ", "    "
+		}
+		return "|-- ", "|   "
+	default:
+		return "", "  "
+	}
+}
+
+func annotation(n *models.TreeNode) string {
+	if n.IsDir {
+		return fmt.Sprintf("(%d files, %s, %d lines, ~%d tokens)", n.Files, formatSize(n.Size), n.Lines, n.Tokens)
+	}
+	return fmt.Sprintf("(%s, %d lines, ~%d tokens)", formatSize(n.Size), n.Lines, n.Tokens)
+}
+
+// formatSize formats a byte count using binary units.
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,362 @@
+// Package server exposes repository packing over HTTP.
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/scanner"
+	"github.com/AndersonTsaiTW/RepoGo/repogo"
+)
+
+// Server serves packing requests for a fixed set of root directories.
+type Server struct {
+	roots    []string // absolute, symlink-free
+	defaults repogo.Options
+}
+
+// New creates a Server restricted to roots. defaults supplies the options
+// (limits, filters, callbacks) that requests start from.
+func New(roots []string, defaults repogo.Options) (*Server, error) {
+	if len(roots) == 0 {
+		return nil, errors.New("at least one root directory is required")
+	}
+	s := &Server{defaults: defaults}
+	for _, r := range roots {
+		abs, err := filepath.Abs(r)
+		if err != nil {
+			return nil, err
+		}
+		if abs, err = filepath.EvalSymlinks(abs); err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", r)
+		}
+		s.roots = append(s.roots, abs)
+	}
+	return s, nil
+}
+
+// Handler returns the HTTP handler serving the API:
+//
+//	GET  /roots              configured root directories
+//	POST /pack               JSON options body, responds with the rendered document
+//	GET  /tree?root=&path=   directory tree (query: format, depth, style, annotate)
+//	GET  /file?root=&path=   raw content of one file
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/roots", s.handleRoots)
+	mux.HandleFunc("/pack", s.handlePack)
+	mux.HandleFunc("/tree", s.handleTree)
+	mux.HandleFunc("/file", s.handleFile)
+	return mux
+}
+
+// packRequest is the JSON body of POST /pack. Paths are relative to Root;
+// MaxFileSize may only lower the server's limit.
+type packRequest struct {
+	Root        string   `json:"root"`
+	Paths       []string `json:"paths"`
+	Include     []string `json:"include"`
+	Exclude     []string `json:"exclude"`
+	MaxFileSize int      `json:"max_file_size"`
+	MaxTokens   int      `json:"max_tokens"`
+	Query       string   `json:"query"`
+	Stats       bool     `json:"stats"`
+	SkipGit     bool     `json:"skip_git"`
+	Format      string   `json:"format"`
+	Tree        struct {
+		Style    string `json:"style"`
+		Depth    int    `json:"depth"`
+		Annotate bool   `json:"annotate"`
+	} `json:"tree"`
+}
+
+func (s *Server) handleRoots(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"roots": s.roots})
+}
+
+func (s *Server) handlePack(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	var req packRequest
+	dec := json.NewDecoder(io.LimitReader(r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	root, err := s.root(req.Root)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	opts := s.options(root)
+	opts.Paths = nil
+	for _, p := range req.Paths {
+		abs, err := scanner.Resolve(root, p)
+		if err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+		opts.Paths = append(opts.Paths, abs)
+	}
+	if len(opts.Paths) == 0 {
+		opts.Paths = []string{root}
+	}
+	opts.Include = append(append([]string(nil), opts.Include...), req.Include...)
+	opts.Exclude = append(append([]string(nil), opts.Exclude...), req.Exclude...)
+	if req.MaxFileSize > 0 && req.MaxFileSize < opts.MaxFileSize {
+		opts.MaxFileSize = req.MaxFileSize
+	}
+	if req.MaxTokens > 0 {
+		opts.MaxTokens = req.MaxTokens
+	}
+	if req.Query != "" {
+		opts.Query = req.Query
+	}
+	opts.Stats = opts.Stats || req.Stats
+	opts.SkipGit = opts.SkipGit || req.SkipGit
+	opts.Tree = repogo.TreeOptions{Style: req.Tree.Style, MaxDepth: req.Tree.Depth, Annotate: req.Tree.Annotate}
+
+	format := strings.ToLower(req.Format)
+	if format == "" {
+		format = "markdown"
+	}
+	if format == "ndjson" {
+		s.streamPack(w, r, opts)
+		return
+	}
+	if _, ok := repogo.LookupRenderer(format); !ok {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown format %q", format))
+		return
+	}
+
+	doc, err := repogo.Pack(r.Context(), opts)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType(format))
+	fw := newFlushWriter(w)
+	_ = repogo.Render(fw, format, doc)
+	_ = fw.Flush()
+}
+
+// streamPack writes one JSON object per line: each file as soon as it is
+// packed, followed by a final object holding the document without files.
+func (s *Server) streamPack(w http.ResponseWriter, r *http.Request, opts repogo.Options) {
+	w.Header().Set("Content-Type", contentType("ndjson"))
+	fw := newFlushWriter(w)
+	enc := json.NewEncoder(fw)
+	opts.OnFile = func(f repogo.FileEntry) error {
+		if err := enc.Encode(map[string]any{"file": f}); err != nil {
+			return err
+		}
+		return fw.Flush()
+	}
+	doc, err := repogo.Pack(r.Context(), opts)
+	if err != nil {
+		// Headers are already sent; report the failure in-band.
+		_ = enc.Encode(map[string]string{"error": err.Error()})
+		_ = fw.Flush()
+		return
+	}
+	doc.Files = nil
+	_ = enc.Encode(map[string]any{"document": doc})
+	_ = fw.Flush()
+}
+
+func (s *Server) handleTree(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	q := r.URL.Query()
+	root, err := s.root(q.Get("root"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	target := root
+	if p := q.Get("path"); p != "" {
+		if target, err = scanner.Resolve(root, p); err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+	}
+
+	opts := s.options(root)
+	opts.Paths = []string{target}
+	opts.Tree = repogo.TreeOptions{Style: q.Get("style"), Annotate: q.Get("annotate") == "true"}
+	if d := q.Get("depth"); d != "" {
+		if opts.Tree.MaxDepth, err = strconv.Atoi(d); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid depth %q", d))
+			return
+		}
+	}
+	tree, err := repogo.Tree(r.Context(), opts)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	if strings.ToLower(q.Get("format")) == "json" {
+		writeJSON(w, http.StatusOK, tree)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.WriteString(w, scanner.RenderTree(tree, opts.Tree))
+}
+
+func (s *Server) handleFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	q := r.URL.Query()
+	root, err := s.root(q.Get("root"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if q.Get("path") == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing path parameter"))
+		return
+	}
+	p, err := scanner.Resolve(root, q.Get("path"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	if !info.Mode().IsRegular() {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%s is not a regular file", q.Get("path")))
+		return
+	}
+	// ServeContent streams the file and handles Range and conditional requests.
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+// options returns the default options for a request against root. Symlinks
+// met during the walk are only followed when they stay inside root, and
+// MaxFileSize is set so that requests can only lower it.
+func (s *Server) options(root string) repogo.Options {
+	opts := s.defaults
+	opts.Root = root
+	if opts.MaxFileSize <= 0 {
+		opts.MaxFileSize = repogo.DefaultMaxFileSize
+	}
+	opts.Filters = append(append([]repogo.Filter(nil), opts.Filters...), repogo.FilterFunc(scanner.ContainedSymlinks(root)))
+	return opts
+}
+
+// root selects a configured root by path or base name; "" picks the first.
+func (s *Server) root(name string) (string, error) {
+	if name == "" {
+		return s.roots[0], nil
+	}
+	for _, r := range s.roots {
+		if name == r || name == filepath.Base(r) {
+			return r, nil
+		}
+	}
+	return "", fmt.Errorf("unknown root %q", name)
+}
+
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, scanner.ErrOutsideRoot):
+		return http.StatusForbidden
+	case errors.Is(err, fs.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func contentType(format string) string {
+	switch format {
+	case "json":
+		return "application/json"
+	case "ndjson":
+		return "application/x-ndjson"
+	case "markdown":
+		return "text/markdown; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed string) {
+	w.Header().Set("Allow", allowed)
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// flushWriter buffers output and pushes it to the client on every Flush,
+// so that large documents reach the client while they are being written.
+type flushWriter struct {
+	*bufio.Writer
+	flusher http.Flusher
+}
+
+func newFlushWriter(w http.ResponseWriter) *flushWriter {
+	fw := &flushWriter{Writer: bufio.NewWriterSize(w, 32*1024)}
+	fw.flusher, _ = w.(http.Flusher)
+	return fw
+}
+
+// Flush writes buffered data and flushes the HTTP response.
+func (fw *flushWriter) Flush() error {
+	if err := fw.Writer.Flush(); err != nil {
+		return err
+	}
+	if fw.flusher != nil {
+		fw.flusher.Flush()
+	}
+	return nil
+}
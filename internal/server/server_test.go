@@ -0,0 +1,148 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AndersonTsaiTW/RepoGo/repogo"
+)
+
+// newTestServer serves a root holding a.txt, a 40-byte long.txt and two
+// symlinks leading to a directory outside it.
+func newTestServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	base := t.TempDir()
+	root := filepath.Join(base, "root")
+	outside := filepath.Join(base, "outside")
+	for _, dir := range []string{filepath.Join(root, "sub"), outside} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(root, "a.txt"):           "hello\n",
+		filepath.Join(root, "sub", "long.txt"): strings.Repeat("0123456789", 4),
+		filepath.Join(outside, "secret.txt"):   "SECRET\n",
+	}
+	for p, content := range files {
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range map[string]string{"escape": outside, "leak.txt": filepath.Join(outside, "secret.txt")} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+	}
+	s, err := New([]string{root}, repogo.Options{MaxFileSize: 16, SkipGit: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return ts, outside
+}
+
+func get(t *testing.T, u string) (int, string) {
+	t.Helper()
+	return response(t)(http.Get(u))
+}
+
+func post(t *testing.T, u, body string) (int, string) {
+	t.Helper()
+	return response(t)(http.Post(u, "application/json", strings.NewReader(body)))
+}
+
+// response returns a function reading the status and body of a response.
+func response(t *testing.T) func(*http.Response, error) (int, string) {
+	return func(resp *http.Response, err error) (int, string) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(body)
+	}
+}
+
+func TestContainment(t *testing.T) {
+	ts, outside := newTestServer(t)
+	paths := []struct {
+		name, path string
+		status     int
+	}{
+		{"inside", "a.txt", http.StatusOK},
+		{"dot-dot", "../outside/secret.txt", http.StatusForbidden},
+		{"dot-dot inside", "sub/../../outside/secret.txt", http.StatusForbidden},
+		{"absolute", filepath.Join(outside, "secret.txt"), http.StatusForbidden},
+		{"slash-absolute", "/etc/passwd", http.StatusForbidden},
+		{"symlinked file", "leak.txt", http.StatusForbidden},
+		{"through a symlinked directory", "escape/secret.txt", http.StatusForbidden},
+		{"missing", "nope.txt", http.StatusNotFound},
+	}
+	for _, tt := range paths {
+		t.Run("file "+tt.name, func(t *testing.T) {
+			status, body := get(t, ts.URL+"/file?path="+url.QueryEscape(tt.path))
+			if status != tt.status || strings.Contains(body, "SECRET") {
+				t.Errorf("GET /file?path=%s = %d %q, want %d", tt.path, status, body, tt.status)
+			}
+		})
+		t.Run("pack "+tt.name, func(t *testing.T) {
+			req, _ := json.Marshal(map[string]any{"paths": []string{tt.path}, "format": "json"})
+			status, body := post(t, ts.URL+"/pack", string(req))
+			if status != tt.status || strings.Contains(body, "SECRET") {
+				t.Errorf("POST /pack %s = %d %q, want %d", tt.path, status, body, tt.status)
+			}
+		})
+	}
+	for _, p := range []string{"..", "escape", "/tmp", filepath.Dir(outside)} {
+		if status, body := get(t, ts.URL+"/tree?path="+url.QueryEscape(p)); status != http.StatusForbidden {
+			t.Errorf("GET /tree?path=%s = %d %q, want %d", p, status, body, http.StatusForbidden)
+		}
+	}
+
+	// Walking the whole root leaves out the links that lead outside it.
+	if status, body := get(t, ts.URL+"/tree"); status != http.StatusOK || strings.Contains(body, "secret") || !strings.Contains(body, "a.txt") {
+		t.Errorf("GET /tree = %d %q", status, body)
+	}
+	if status, body := post(t, ts.URL+"/pack", `{"format":"json"}`); status != http.StatusOK || strings.Contains(body, "SECRET") || !strings.Contains(body, "hello") {
+		t.Errorf("POST /pack = %d %q", status, body)
+	}
+}
+
+func TestPackMaxFileSize(t *testing.T) {
+	ts, _ := newTestServer(t)
+	tests := []struct {
+		requested int
+		want      int // bytes of long.txt packed
+	}{
+		{0, 16},
+		{8, 8},
+		{1 << 40, 16}, // cannot raise the server's limit
+	}
+	for _, tt := range tests {
+		req, _ := json.Marshal(map[string]any{"paths": []string{"sub"}, "format": "json", "max_file_size": tt.requested})
+		status, body := post(t, ts.URL+"/pack", string(req))
+		if status != http.StatusOK {
+			t.Fatalf("POST /pack = %d %q", status, body)
+		}
+		var doc repogo.OutputDoc
+		if err := json.Unmarshal([]byte(body), &doc); err != nil {
+			t.Fatal(err)
+		}
+		if len(doc.Files) != 1 || len(doc.Files[0].Content) != tt.want {
+			t.Errorf("max_file_size %d packed %+v, want %d bytes", tt.requested, doc.Files, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Package stats aggregates per-language and per-directory statistics.
+package stats
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/analyzer"
+	"github.com/AndersonTsaiTW/RepoGo/internal/models"
+)
+
+// TopN is the number of entries kept in the largest files and heaviest
+// directories lists.
+const TopN = 10
+
+// Collector accumulates file statistics during a scan.
+type Collector struct {
+	languages map[string]*models.GroupStats
+	topDirs   map[string]*models.GroupStats
+	allDirs   map[string]*models.GroupStats
+	files     []models.FileStats
+	history   map[string]*models.FileHistory
+}
+
+// NewCollector returns an empty Collector.
+func NewCollector() *Collector {
+	return &Collector{
+		languages: map[string]*models.GroupStats{},
+		topDirs:   map[string]*models.GroupStats{},
+		allDirs:   map[string]*models.GroupStats{},
+		history:   map[string]*models.FileHistory{},
+	}
+}
+
+// Add records one file. relPath uses forward slashes and is relative to the
+// scan root; language is empty for unrecognized text files.
+func (c *Collector) Add(relPath, language string, binary bool, size int64, lines analyzer.LineCounts, tokens int) {
+	switch {
+	case binary:
+		language = "(binary)"
+	case language == "":
+		language = "(other)"
+	}
+	addTo(group(c.languages, language), size, lines, tokens)
+
+	top := "."
+	if i := strings.IndexByte(relPath, '/'); i >= 0 {
+		top = relPath[:i]
+	}
+	addTo(group(c.topDirs, top), size, lines, tokens)
+
+	for dir := path.Dir(relPath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		addTo(group(c.allDirs, dir), size, lines, tokens)
+	}
+
+	c.files = append(c.files, models.FileStats{
+		Path:     relPath,
+		Language: language,
+		Bytes:    size,
+		Lines:    lines.Total(),
+		Tokens:   tokens,
+	})
+}
+
+// AddHistory records the git history of a file added with Add, making it
+// a candidate for the hotspot list.
+func (c *Collector) AddHistory(relPath string, h *models.FileHistory) {
+	if h != nil {
+		c.history[relPath] = h
+	}
+}
+
+// Result returns the collected statistics, with groups ordered by token
+// count (heaviest first).
+func (c *Collector) Result() *models.Stats {
+	files := append([]models.FileStats(nil), c.files...)
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].Bytes != files[j].Bytes {
+			return files[i].Bytes > files[j].Bytes
+		}
+		return files[i].Path < files[j].Path
+	})
+	if len(files) > TopN {
+		files = files[:TopN]
+	}
+	heaviest := sorted(c.allDirs)
+	if len(heaviest) > TopN {
+		heaviest = heaviest[:TopN]
+	}
+	return &models.Stats{
+		ByLanguage:          sorted(c.languages),
+		ByDirectory:         sorted(c.topDirs),
+		LargestFiles:        files,
+		HeaviestDirectories: heaviest,
+		Hotspots:            c.hotspots(),
+	}
+}
+
+// hotspots returns the files with the most commits, then the most authors.
+func (c *Collector) hotspots() []models.FileStats {
+	var out []models.FileStats
+	for _, f := range c.files {
+		if h := c.history[f.Path]; h != nil && h.Commits > 0 {
+			f.Commits, f.Authors = h.Commits, h.Authors
+			out = append(out, f)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Commits != out[j].Commits {
+			return out[i].Commits > out[j].Commits
+		}
+		return out[i].Authors > out[j].Authors
+	})
+	if len(out) > TopN {
+		out = out[:TopN]
+	}
+	return out
+}
+
+func group(m map[string]*models.GroupStats, name string) *models.GroupStats {
+	g, ok := m[name]
+	if !ok {
+		g = &models.GroupStats{Name: name}
+		m[name] = g
+	}
+	return g
+}
+
+func addTo(g *models.GroupStats, size int64, lines analyzer.LineCounts, tokens int) {
+	g.Files++
+	g.CodeLines += lines.Code
+	g.CommentLines += lines.Comment
+	g.BlankLines += lines.Blank
+	g.Bytes += size
+	g.Tokens += tokens
+}
+
+func sorted(m map[string]*models.GroupStats) []models.GroupStats {
+	out := make([]models.GroupStats, 0, len(m))
+	for _, g := range m {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Tokens != out[j].Tokens {
+			return out[i].Tokens > out[j].Tokens
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
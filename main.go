@@ -436,13 +436,13 @@ func estimateTokens(s string) int {
 }
 
 func renderMarkdown(w io.Writer, doc OutputDoc) {
-	fmt.Fprintln(w, "# Repository Context\n")
-	fmt.Fprintln(w, "## File System Location\n")
-	fmt.Fprintln(w, doc.Location, "\n")
+	fmt.Fprint(w, "# Repository Context\n\n")
+	fmt.Fprint(w, "## File System Location\n\n")
+	fmt.Fprint(w, doc.Location, "\n\n")
 
-	fmt.Fprintln(w, "## Git Info\n")
+	fmt.Fprint(w, "## Git Info\n\n")
 	if doc.Git == nil {
-		fmt.Fprintln(w, "- Not a git repository\n")
+		fmt.Fprint(w, "- Not a git repository\n\n")
 	} else {
 		fmt.Fprintf(w, "- Commit: %s\n", doc.Git.Commit)
 		fmt.Fprintf(w, "- Branch: %s\n", doc.Git.Branch)
@@ -451,9 +451,9 @@ func renderMarkdown(w io.Writer, doc OutputDoc) {
 	}
 
 	fmt.Fprintln(w, "## Structure")
-	fmt.Fprintln(w, doc.Structure, "\n")
+	fmt.Fprint(w, doc.Structure, "\n\n")
 
-	fmt.Fprintln(w, "## File Contents\n")
+	fmt.Fprint(w, "## File Contents\n\n")
 	for _, f := range doc.Files {
 		fmt.Fprintf(w, "### File: %s\n", f.Path)
 		if f.ReadErrorMessage != "" && f.Content == "" && !f.IsBinary {
@@ -466,7 +466,7 @@ func renderMarkdown(w io.Writer, doc OutputDoc) {
 		}
 		fmt.Fprintf(w, "```%s\n%s\n```\n\n", f.LanguageHint, f.Content)
 		if f.Truncated {
-			fmt.Fprintln(w, "_[truncated]_\n")
+			fmt.Fprint(w, "_[truncated]_\n\n")
 		}
 		if f.ReadErrorMessage != "" {
 			fmt.Fprintf(w, "_Note: %s_\n\n", f.ReadErrorMessage)
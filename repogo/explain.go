@@ -0,0 +1,28 @@
+package repogo
+
+import (
+	"context"
+	"sort"
+)
+
+// Explain runs the scan and packing described by opts and reports, for
+// every candidate path, whether it was included, stubbed, omitted or
+// excluded and the rule responsible. Paths below an excluded directory are
+// listed with RuleParent. Repository metadata, statistics and the commit
+// log are not collected, and opts.OnFile is not called.
+func Explain(ctx context.Context, opts Options) (*Explanation, error) {
+	var decisions []Decision
+	opts.onDecision = func(d Decision) { decisions = append(decisions, d) }
+	opts.OnFile = nil
+	opts.Stats = false
+	opts.Log = 0
+	if opts.Sort != SortChurn {
+		opts.SkipGit = true
+	}
+	doc, err := Pack(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(decisions, func(i, j int) bool { return decisions[i].Path < decisions[j].Path })
+	return &Explanation{Location: doc.Location, Decisions: decisions}, nil
+}
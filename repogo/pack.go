@@ -0,0 +1,733 @@
+package repogo
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/analyzer"
+	"github.com/AndersonTsaiTW/RepoGo/internal/deps"
+	"github.com/AndersonTsaiTW/RepoGo/internal/git"
+	"github.com/AndersonTsaiTW/RepoGo/internal/imports"
+	"github.com/AndersonTsaiTW/RepoGo/internal/models"
+	"github.com/AndersonTsaiTW/RepoGo/internal/rank"
+	"github.com/AndersonTsaiTW/RepoGo/internal/scanner"
+	"github.com/AndersonTsaiTW/RepoGo/internal/stats"
+)
+
+// scannedFile is a file read during the scan, before the token budget applies.
+type scannedFile struct {
+	entry  models.FileEntry
+	lang   *analyzer.Language
+	lines  int
+	tokens int
+	sum    analyzer.Sum
+	// rule and detail explain why the content was replaced by a note.
+	rule   string
+	detail string
+	// stubbed is the content replaced by a note, and readTokens the tokens
+	// of the file as read; statistics count them rather than the note.
+	stubbed    string
+	readTokens int
+}
+
+// scanResult holds everything read from disk for one run.
+type scanResult struct {
+	root  string
+	files []scannedFile
+	tree  *models.TreeNode
+	graph string // rendered import graph, if requested
+	deps  *models.Dependencies
+	// skipped counts generated, minified and vendored files by kind, and
+	// excluded the paths left out by the depth, size and time limits by
+	// reason.
+	skipped  map[string]int
+	excluded map[string]int
+}
+
+// Pack scans opts.Paths and assembles the output document. Every file is
+// read and measured so that the structure and statistics cover the whole
+// scan; only the packed contents are cut off once MaxTokens is exceeded.
+func Pack(ctx context.Context, opts Options) (*OutputDoc, error) {
+	switch opts.Sort {
+	case "", SortPath, SortChurn:
+	default:
+		return nil, fmt.Errorf("unknown sort order %q (want %s or %s)", opts.Sort, SortPath, SortChurn)
+	}
+	switch opts.Compress {
+	case "", CompressNone, CompressWhitespace, CompressComments, CompressIndent:
+	default:
+		return nil, fmt.Errorf("unknown compression level %q (want %s, %s, %s or %s)", opts.Compress, CompressNone, CompressWhitespace, CompressComments, CompressIndent)
+	}
+	res, err := scan(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &OutputDoc{Location: res.root}
+	if !opts.SkipGit {
+		if gi, err := git.GetInfo(res.root); err == nil {
+			doc.Git = gi
+		} // Otherwise leave empty, renderers report "Not a git repository"
+	}
+	if (opts.History || opts.Sort == SortChurn) && doc.Git != nil {
+		attachHistory(res, opts)
+	}
+	doc.Structure = "```\n" + scanner.RenderTree(res.tree, opts.Tree) + "```"
+	doc.ImportGraph = res.graph
+	doc.Dependencies = res.deps
+
+	if opts.Stats {
+		collector := stats.NewCollector()
+		for _, sf := range res.files {
+			if sf.entry.ReadErrorMessage != "" || sf.entry.Symlink != "" {
+				continue
+			}
+			content := sf.entry.Content
+			if sf.stubbed != "" {
+				content = sf.stubbed
+			}
+			collector.Add(sf.entry.Path, sf.entry.Language, sf.entry.IsBinary, sf.entry.Size, analyzer.CountLines(content, sf.lang), sf.readTokens)
+			collector.AddHistory(sf.entry.Path, sf.entry.History)
+		}
+		doc.Stats = collector.Result()
+	}
+
+	if opts.Query != "" {
+		doc.Query = opts.Query
+		rankFiles(res.files, opts.Query)
+	} else if opts.Sort == SortChurn {
+		sort.SliceStable(res.files, func(i, j int) bool {
+			return churn(res.files[i]) > churn(res.files[j])
+		})
+	}
+
+	var totalTokens, totalLines, binaryCount, skippedByToken, deduplicated, savedTokens, compressSaved int
+	var dups *analyzer.Duplicates
+	if opts.Dedupe {
+		dups = analyzer.NewDuplicates(opts.DedupeThreshold)
+	}
+
+	for i, sf := range res.files {
+		entry := sf.entry
+		if entry.IsBinary {
+			binaryCount++
+		}
+		totalLines += sf.lines
+
+		var origins []int // original line numbers of compressed content
+		if entry.Content != "" && len(entry.Snippets) == 0 {
+			if c, lines := analyzer.Compress(entry.Content, sf.lang, opts.Compress); c != entry.Content {
+				origins = lines
+				tokens := analyzer.EstimateTokens(c)
+				entry.Content = c
+				entry.TokensSaved = sf.tokens - tokens
+				compressSaved += entry.TokensSaved
+				sf.tokens = tokens
+				sf.sum = analyzer.HashContent([]byte(c))
+			}
+		}
+		if dups != nil && entry.Content != "" && !entry.Truncated && len(entry.Snippets) == 0 {
+			if dup := dups.Find(entry.Path, entry.Language, sf.sum, entry.Content); dup != nil {
+				if tokens := analyzer.EstimateTokens(dup.Diff); tokens < sf.tokens {
+					deduplicated++
+					savedTokens += sf.tokens - tokens
+					entry.Duplicate = dup
+					entry.Content = ""
+					sf.tokens = tokens
+				}
+			}
+		}
+
+		overBudget := opts.MaxTokens > 0 && totalTokens+sf.tokens > opts.MaxTokens
+		if len(entry.Snippets) > 0 {
+			entry.Content = ""
+		}
+		if opts.LineNumbers && !overBudget && entry.Content != "" {
+			entry.LineRanges = lineRanges(entry.Content, origins)
+		}
+		if overBudget {
+			skippedByToken++
+			entry.Content = ""
+			entry.Snippets = nil
+			entry.Truncated = true
+			entry.ReadErrorMessage = fmt.Sprintf("omitted due to --max-tokens budget (would add ~%d tokens)", sf.tokens)
+		} else {
+			totalTokens += sf.tokens
+		}
+		if opts.onDecision != nil {
+			d := decide(sf, entry)
+			if overBudget {
+				d.Decision, d.Rule = models.DecisionOmitted, RuleBudget
+				d.Detail = fmt.Sprintf("~%d tokens on top of ~%d packed would exceed -max-tokens %d", sf.tokens, totalTokens, opts.MaxTokens)
+			}
+			opts.onDecision(d)
+			if overBudget {
+				for _, rest := range res.files[i+1:] {
+					opts.onDecision(models.Decision{Path: rest.entry.Path, Decision: models.DecisionOmitted, Rule: RuleBudget, Detail: "after the token budget ran out"})
+				}
+			}
+		}
+		doc.Files = append(doc.Files, entry)
+		if opts.OnFile != nil {
+			if err := opts.OnFile(entry); err != nil {
+				return nil, err
+			}
+		}
+		if overBudget {
+			break
+		}
+	}
+
+	if opts.Log > 0 && doc.Git != nil {
+		var paths []string
+		if opts.LogPackedOnly {
+			paths = logPaths(doc.Files)
+		}
+		commits, err := git.Log(res.root, opts.Log, opts.LogStats, paths...)
+		if err != nil && opts.OnWarning != nil {
+			opts.OnWarning(res.root, err)
+		}
+		doc.Log = commits
+	}
+
+	doc.Summary = models.Summary{
+		TotalFiles:          len(doc.Files),
+		TotalLines:          totalLines,
+		EstimatedTokens:     totalTokens,
+		SkippedByLimit:      skippedByToken,
+		BinaryFilesCount:    binaryCount,
+		Deduplicated:        deduplicated,
+		DedupeSavedTokens:   savedTokens,
+		CompressSavedTokens: compressSaved,
+	}
+	if len(res.skipped) > 0 {
+		doc.Summary.Skipped = res.skipped
+	}
+	if len(res.excluded) > 0 {
+		doc.Summary.Excluded = res.excluded
+	}
+	return doc, nil
+}
+
+// decide reports what happened to a file that passed the scan.
+func decide(sf scannedFile, entry models.FileEntry) models.Decision {
+	d := models.Decision{Path: entry.Path, Decision: models.DecisionIncluded}
+	switch {
+	case entry.Symlink != "":
+		d.Decision, d.Rule, d.Detail = models.DecisionStubbed, RuleSymlink, "-symlinks record: link to "+entry.Symlink
+	case sf.rule != "":
+		d.Decision, d.Rule, d.Detail = models.DecisionStubbed, sf.rule, sf.detail
+	case entry.ReadErrorMessage != "":
+		d.Decision, d.Rule, d.Detail = models.DecisionStubbed, RuleUnreadable, entry.ReadErrorMessage
+	case entry.IsBinary:
+		d.Decision, d.Rule, d.Detail = models.DecisionStubbed, RuleBinary, "binary content is not packed"
+	case entry.Duplicate != nil && entry.Duplicate.Diff == "":
+		d.Decision, d.Rule, d.Detail = models.DecisionStubbed, RuleDedupe, "identical to "+entry.Duplicate.Of
+	case entry.Duplicate != nil:
+		d.Rule, d.Detail = RuleDedupe, fmt.Sprintf("packed as a diff against %s (%.0f%% similar)", entry.Duplicate.Of, entry.Duplicate.Similarity*100)
+	case len(entry.Snippets) > 0:
+		d.Rule, d.Detail = RuleGrep, fmt.Sprintf("%d matching snippet(s)", len(entry.Snippets))
+	case entry.Truncated:
+		d.Rule, d.Detail = RuleMaxFileSize, fmt.Sprintf("truncated (%d bytes)", entry.Size)
+	}
+	return d
+}
+
+// lineRanges maps the lines of content onto the original file: a single
+// range for content read verbatim, or one range per run of consecutive
+// lines kept by compression.
+func lineRanges(content string, origins []int) []models.LineRange {
+	if origins == nil {
+		return []models.LineRange{{Start: 1, End: strings.Count(strings.TrimSuffix(content, "\n"), "\n") + 1}}
+	}
+	var ranges []models.LineRange
+	for _, n := range origins {
+		if last := len(ranges) - 1; last >= 0 && ranges[last].End == n-1 {
+			ranges[last].End = n
+			continue
+		}
+		ranges = append(ranges, models.LineRange{Start: n, End: n})
+	}
+	return ranges
+}
+
+// attachHistory sets the git history of every scanned file. Failures, such
+// as scanning outside a repository, leave the files without history.
+func attachHistory(res *scanResult, opts Options) {
+	paths := make([]string, len(res.files))
+	for i, sf := range res.files {
+		paths[i] = sf.entry.Path
+	}
+	history, err := git.FileHistory(res.root, opts.HistorySince, paths)
+	if err != nil {
+		if opts.OnWarning != nil {
+			opts.OnWarning(res.root, err)
+		}
+		return
+	}
+	for i := range res.files {
+		res.files[i].entry.History = history[res.files[i].entry.Path]
+	}
+}
+
+func churn(sf scannedFile) int {
+	if sf.entry.History == nil {
+		return 0
+	}
+	return sf.entry.History.Commits
+}
+
+// maxLogPaths bounds the pathspecs passed to git log; beyond it the
+// filter is widened to the top-level entries containing the files.
+const maxLogPaths = 200
+
+// logPaths returns the pathspecs selecting the commits that touch files.
+func logPaths(files []models.FileEntry) []string {
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	if len(paths) <= maxLogPaths {
+		return paths
+	}
+	seen := map[string]bool{}
+	var top []string
+	for _, p := range paths {
+		first, _, _ := strings.Cut(p, "/")
+		if !seen[first] {
+			seen[first] = true
+			top = append(top, first)
+		}
+	}
+	return top
+}
+
+// rankFiles scores files against query and sorts them by descending
+// score; files of equal score keep their path order.
+func rankFiles(files []scannedFile, query string) {
+	ix := rank.NewIndex()
+	for _, sf := range files {
+		ix.Add(sf.entry.Path, sf.entry.Content)
+	}
+	for i, score := range ix.Scores(query) {
+		files[i].entry.Score = math.Round(score*1000) / 1000
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].entry.Score > files[j].entry.Score
+	})
+}
+
+// Tree scans opts.Paths and returns the directory tree, with each node
+// annotated with size, line and estimated token counts.
+func Tree(ctx context.Context, opts Options) (*TreeNode, error) {
+	res, err := scan(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return res.tree, nil
+}
+
+// collection holds the result of walking the inputs, before any file is read.
+type collection struct {
+	root      string
+	files     []string // absolute paths in walk order
+	graph     string
+	excluded  map[string]int
+	stubs     []scanner.TreeFile // submodules shown as single entries
+	links     []scannedFile      // symbolic links recorded with their targets
+	linkFiles []scanner.TreeFile
+}
+
+// Files returns the absolute paths of the files selected by opts, in the
+// order they were found, without reading them. Grep and the policy for
+// generated files apply to contents and are not consulted.
+func Files(ctx context.Context, opts Options) ([]string, error) {
+	c, err := collect(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.files, nil
+}
+
+// collect walks the inputs of opts and applies the globs, filters and limits.
+func collect(ctx context.Context, opts Options) (*collection, error) {
+	excluded := map[string]int{}
+	paths := opts.Paths
+	root := opts.Root
+	if opts.Files != nil {
+		if root == "" {
+			root = "."
+		}
+		paths = listedPaths(root, opts.Files, excluded, opts.OnWarning, opts.onDecision)
+	} else if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	if root == "" {
+		var err error
+		if root, err = scanner.ResolveRoot(paths); err != nil {
+			return nil, err
+		}
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	var graph string
+	if len(opts.FollowImports) > 0 || opts.ImportGraph {
+		var modRoot string
+		if paths, modRoot, graph, err = followImports(ctx, root, paths, opts); err != nil {
+			return nil, err
+		}
+		if opts.Root == "" && len(opts.FollowImports) > 0 {
+			// Imported packages may live outside the scanned directory.
+			root = modRoot
+		}
+	}
+	switch opts.Generated {
+	case "", GeneratedStub, GeneratedSkip, GeneratedInclude:
+	default:
+		return nil, fmt.Errorf("unknown policy %q for generated files (want %s, %s or %s)", opts.Generated, GeneratedStub, GeneratedSkip, GeneratedInclude)
+	}
+	submodules, err := findSubmodules(root, opts.Submodules)
+	if err != nil {
+		return nil, err
+	}
+	keep := func(rel string, isDir bool) bool {
+		for _, f := range opts.Filters {
+			if !f.Keep(rel, isDir) {
+				return false
+			}
+		}
+		return true
+	}
+	var stubs []scanner.TreeFile
+	var links []scannedFile
+	var linkFiles []scanner.TreeFile
+	scanOpts := scanner.Options{
+		Includes:        opts.Include,
+		Excludes:        opts.Exclude,
+		Presets:         opts.Presets,
+		OnWarning:       opts.OnWarning,
+		Symlinks:        opts.Symlinks,
+		SymlinksOutside: opts.SymlinksOutside,
+		NewerThan:       opts.NewerThan,
+		OlderThan:       opts.OlderThan,
+		MinSize:         opts.MinSize,
+		MaxSize:         opts.MaxSize,
+		MaxDepth:        opts.MaxDepth,
+		OnExclude:       func(_, reason string) { excluded[reason]++ },
+		CheckParents:    opts.Files != nil,
+		OnDecision: func(d models.Decision) {
+			if opts.onDecision == nil {
+				return
+			}
+			if _, ok := submodules[d.Path]; ok && d.Rule == RuleSubmodule && opts.Submodules != SubmodulesSkip {
+				d.Decision = models.DecisionStubbed
+			}
+			opts.onDecision(d)
+		},
+		OnSymlink: func(p, target string) {
+			rel, _ := filepath.Rel(root, p)
+			links = append(links, scannedFile{entry: models.FileEntry{Path: filepath.ToSlash(rel), Symlink: target}})
+			linkFiles = append(linkFiles, scanner.TreeFile{Path: p, Note: "symlink to " + target})
+		},
+		Reject: func(rel string, isDir bool) (string, string) {
+			// The root's own .git is left to the globs, as before.
+			if !opts.NestedGit && rel != ".git" && path.Base(rel) == ".git" {
+				return RuleNestedGit, "nested .git directory"
+			}
+			if !keep(rel, isDir) {
+				return RuleFilter, "rejected by a filter"
+			}
+			sm, ok := submodules[rel]
+			if !ok || !isDir || opts.Submodules == SubmodulesInclude {
+				return "", ""
+			}
+			note := submoduleNote(sm)
+			if opts.Submodules != SubmodulesSkip {
+				stubs = append(stubs, scanner.TreeFile{
+					Path:  filepath.Join(root, filepath.FromSlash(rel)),
+					IsDir: true,
+					Note:  note,
+				})
+			}
+			return RuleSubmodule, "-submodules " + cmp.Or(opts.Submodules, SubmodulesStub) + ": " + note
+		},
+	}
+	files, err := scanner.CollectFiles(ctx, root, paths, scanOpts)
+	if err != nil {
+		return nil, err
+	}
+	return &collection{root: root, files: files, graph: graph, excluded: excluded, stubs: stubs, links: links, linkFiles: linkFiles}, nil
+}
+
+// scan collects and reads the files selected by opts.
+func scan(ctx context.Context, opts Options) (*scanResult, error) {
+	c, err := collect(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	root := c.root
+	maxFileSize := opts.MaxFileSize
+	if maxFileSize == 0 {
+		maxFileSize = DefaultMaxFileSize
+	}
+	exclude := func(rel, rule, detail string) {
+		if opts.onDecision != nil {
+			opts.onDecision(models.Decision{Path: rel, Decision: models.DecisionExcluded, Rule: rule, Detail: detail})
+		}
+	}
+
+	var matcher *analyzer.Matcher
+	if len(opts.Grep) > 0 {
+		if matcher, err = analyzer.NewMatcher(opts.Grep, opts.GrepAll); err != nil {
+			return nil, err
+		}
+	}
+
+	var attrs *analyzer.Attributes
+	if opts.Generated != GeneratedInclude {
+		attrs = analyzer.LoadAttributes(root)
+	}
+	res := &scanResult{root: root, graph: c.graph, skipped: map[string]int{}, excluded: c.excluded}
+	var treeFiles []scanner.TreeFile
+	for _, p := range c.files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		rel, _ := filepath.Rel(root, p)
+		sf, tf := readFile(p, filepath.ToSlash(rel), maxFileSize)
+		if matcher != nil {
+			if sf.entry.IsBinary {
+				exclude(sf.entry.Path, RuleGrep, "binary content cannot match -grep")
+				continue
+			}
+			if !matcher.Match(sf.entry.Content) {
+				exclude(sf.entry.Path, RuleGrep, "content does not match -grep "+strings.Join(opts.Grep, ", "))
+				continue
+			}
+			if opts.Snippets {
+				// Only the snippets are packed, so only they count toward the budget.
+				sf.entry.Snippets = matcher.Snippets(sf.entry.Content, opts.ContextLines)
+				sf.tokens = 0
+				for _, s := range sf.entry.Snippets {
+					sf.tokens += analyzer.EstimateTokens(s.Content)
+				}
+				tf.Tokens = sf.tokens
+			}
+		}
+		if !opts.SkipDependencies && !sf.entry.IsBinary {
+			summarizeDependencies(res, p, &sf, &tf, opts.OnWarning)
+		}
+		if opts.Generated != GeneratedInclude && !sf.entry.IsBinary && sf.entry.Omitted == "" {
+			if kind, reason := analyzer.Classify(sf.entry.Path, []byte(sf.entry.Content), attrs); kind != "" {
+				res.skipped[kind]++
+				if opts.Generated == GeneratedSkip {
+					exclude(sf.entry.Path, RuleGenerated, kind+" file: "+reason)
+					continue
+				}
+				sf.rule, sf.detail = RuleGenerated, kind+" file: "+reason
+				sf.entry.Omitted = kind + " file: " + reason
+				sf.stubbed = sf.entry.Content
+				sf.entry.Content, sf.entry.Snippets = "", nil
+				sf.tokens, tf.Tokens = 0, 0
+			}
+		}
+		res.files = append(res.files, sf)
+		treeFiles = append(treeFiles, tf)
+	}
+	if len(c.links) > 0 && matcher != nil { // links have no content to grep
+		for _, l := range c.links {
+			exclude(l.entry.Path, RuleGrep, "symbolic links have no content to match -grep")
+		}
+	} else if len(c.links) > 0 {
+		res.files = append(res.files, c.links...)
+		sort.SliceStable(res.files, func(i, j int) bool { return res.files[i].entry.Path < res.files[j].entry.Path })
+		treeFiles = append(treeFiles, c.linkFiles...)
+	}
+	res.tree = scanner.BuildTree(root, append(treeFiles, c.stubs...))
+	return res, nil
+}
+
+// listedPaths resolves files against root and returns those that exist.
+// The others are reported to onWarning and onDecision and counted in
+// excluded under RuleFilesFrom.
+func listedPaths(root string, files []string, excluded map[string]int, onWarning func(string, error), onDecision func(models.Decision)) []string {
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		p := f
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(root, p)
+		}
+		if _, err := os.Lstat(p); err != nil {
+			excluded[RuleFilesFrom]++
+			if onWarning != nil {
+				onWarning(f, err)
+			}
+			if onDecision != nil {
+				onDecision(models.Decision{Path: filepath.ToSlash(f), Decision: models.DecisionExcluded, Rule: RuleFilesFrom, Detail: err.Error()})
+			}
+			continue
+		}
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// findSubmodules returns the git submodules below root keyed by their
+// slash-separated relative path, after validating mode.
+func findSubmodules(root, mode string) (map[string]models.Submodule, error) {
+	switch mode {
+	case "", SubmodulesStub, SubmodulesInclude, SubmodulesSkip:
+	default:
+		return nil, fmt.Errorf("unknown submodule mode %q (want %s, %s or %s)", mode, SubmodulesStub, SubmodulesInclude, SubmodulesSkip)
+	}
+	list, err := git.Submodules(root)
+	if err != nil {
+		return nil, nil // not a repository
+	}
+	submodules := make(map[string]models.Submodule, len(list))
+	for _, sm := range list {
+		submodules[sm.Path] = sm
+	}
+	return submodules, nil
+}
+
+// submoduleNote describes a stubbed submodule in the structure.
+func submoduleNote(sm models.Submodule) string {
+	short := sm.Commit
+	if len(short) > 12 {
+		short = short[:12]
+	}
+	note := "submodule at " + short
+	if sm.Status != "" {
+		note += ", " + sm.Status
+	}
+	return note
+}
+
+// summarizeDependencies records the dependencies declared by a manifest or
+// pinned by a lock file. Lock files are summarized rather than packed.
+func summarizeDependencies(res *scanResult, p string, sf *scannedFile, tf *scanner.TreeFile, onWarning func(string, error)) {
+	rel := sf.entry.Path
+	isLock := deps.IsLockFile(rel)
+	if !isLock && !deps.IsManifest(rel) {
+		return
+	}
+	content := []byte(sf.entry.Content)
+	if int64(len(content)) < sf.entry.Size {
+		// Only part of the file was read; parse all of it.
+		full, err := os.ReadFile(p)
+		if err != nil {
+			if onWarning != nil {
+				onWarning(p, err)
+			}
+			return
+		}
+		content = full
+	}
+	if res.deps == nil {
+		res.deps = &models.Dependencies{}
+	}
+	if isLock {
+		l := deps.SummarizeLockFile(rel, content)
+		res.deps.LockFiles = append(res.deps.LockFiles, *l)
+		sf.stubbed = sf.entry.Content
+		sf.entry.Content = ""
+		sf.entry.Truncated = false
+		sf.entry.Omitted = fmt.Sprintf("%s lock file pinning %d packages (see Dependencies)", l.Ecosystem, l.Packages)
+		sf.rule, sf.detail = RuleDependencies, sf.entry.Omitted
+		sf.tokens, tf.Tokens = 0, 0
+		return
+	}
+	m, err := deps.ParseManifest(rel, content)
+	if err != nil {
+		if onWarning != nil {
+			onWarning(p, fmt.Errorf("parse manifest: %w", err))
+		}
+		return
+	}
+	res.deps.Manifests = append(res.deps.Manifests, *m)
+}
+
+// followImports loads the import graph of the Go module containing root.
+// When opts.FollowImports is set it returns the .go files of the followed
+// packages in place of paths. It also returns the module root and, if
+// requested, the rendered graph.
+func followImports(ctx context.Context, root string, paths []string, opts Options) ([]string, string, string, error) {
+	modRoot, err := imports.FindModuleRoot(root)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("follow imports: %w", err)
+	}
+	g, err := imports.Load(ctx, modRoot)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("follow imports: %w", err)
+	}
+	var pkgs []string
+	if len(opts.FollowImports) > 0 {
+		var start []string
+		for _, target := range opts.FollowImports {
+			pkg, err := g.Find(target)
+			if err != nil {
+				return nil, "", "", err
+			}
+			start = append(start, pkg.ImportPath)
+		}
+		pkgs = g.Closure(start, opts.ReverseDeps)
+		paths = nil
+		for _, ip := range pkgs {
+			paths = append(paths, g.Packages[ip].Files...)
+		}
+	}
+	var graph string
+	if opts.ImportGraph {
+		graph = g.Render(pkgs)
+	}
+	return paths, modRoot, graph, nil
+}
+
+// readFile reads and measures one file. Problems reading it are recorded on
+// the entry rather than returned.
+func readFile(p, rel string, maxFileSize int) (scannedFile, scanner.TreeFile) {
+	sf := scannedFile{entry: models.FileEntry{Path: rel}}
+	tf := scanner.TreeFile{Path: p}
+
+	info, err := os.Stat(p)
+	if err != nil {
+		sf.entry.ReadErrorMessage = err.Error()
+		return sf, tf
+	}
+	sf.entry.Size = info.Size()
+	tf.Size = info.Size()
+
+	f, err := os.Open(p)
+	if err != nil {
+		sf.entry.ReadErrorMessage = err.Error()
+		return sf, tf
+	}
+	content, isBinary, truncated, lines := analyzer.ReadFileContent(f, maxFileSize)
+	_ = f.Close()
+	sf.entry.IsBinary = isBinary
+	sf.entry.Truncated = truncated
+
+	if !isBinary {
+		sf.entry.Content = string(content)
+		sf.sum = analyzer.HashContent(content)
+		if sf.lang = analyzer.DetectLanguage(rel, content); sf.lang != nil {
+			sf.entry.Language = sf.lang.Name
+			sf.entry.LanguageHint = sf.lang.Fence
+		}
+		sf.lines = lines
+	}
+	sf.tokens = analyzer.EstimateTokens(sf.entry.Content)
+	sf.readTokens = sf.tokens
+	tf.Lines, tf.Tokens = sf.lines, sf.tokens
+	return sf, tf
+}
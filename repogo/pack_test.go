@@ -0,0 +1,275 @@
+package repogo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/analyzer"
+	"github.com/AndersonTsaiTW/RepoGo/internal/renderer"
+)
+
+// writeFiles creates the given files, with their parent directories, below
+// root.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestPackGenerated checks each policy for generated files, including the
+// stub note packed by default.
+func TestPackGenerated(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.go":     "package main\n\nfunc main() {}\n",
+		"types.pb.go": "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage main\n\ntype Msg struct{}\n",
+	})
+	const note = `generated file: "Code generated ... DO NOT EDIT." header`
+	tests := []struct {
+		policy  string
+		paths   []string
+		omitted string // Omitted of types.pb.go
+	}{
+		{"", []string{"main.go", "types.pb.go"}, note},
+		{GeneratedStub, []string{"main.go", "types.pb.go"}, note},
+		{GeneratedSkip, []string{"main.go"}, ""},
+		{GeneratedInclude, []string{"main.go", "types.pb.go"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run("policy "+tt.policy, func(t *testing.T) {
+			doc, err := Pack(context.Background(), Options{Paths: []string{root}, Generated: tt.policy, SkipGit: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var paths []string
+			for _, f := range doc.Files {
+				paths = append(paths, f.Path)
+				if f.Path != "types.pb.go" {
+					continue
+				}
+				if f.Omitted != tt.omitted {
+					t.Errorf("Omitted = %q, want %q", f.Omitted, tt.omitted)
+				}
+				if stubbed := f.Content == ""; stubbed != (tt.omitted != "") {
+					t.Errorf("Content = %q with Omitted %q", f.Content, f.Omitted)
+				}
+			}
+			if got := strings.Join(paths, " "); got != strings.Join(tt.paths, " ") {
+				t.Errorf("files = %s, want %s", got, strings.Join(tt.paths, " "))
+			}
+			wantSkipped := 1
+			if tt.policy == GeneratedInclude {
+				wantSkipped = 0
+			}
+			if got := doc.Summary.Skipped["generated"]; got != wantSkipped {
+				t.Errorf("Summary.Skipped[generated] = %d, want %d", got, wantSkipped)
+			}
+			if tt.omitted == "" {
+				return
+			}
+			var out bytes.Buffer
+			if err := Render(&out, "markdown", doc); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out.String(), "_Omitted: "+note+"._") {
+				t.Errorf("markdown lacks the stub note:\n%s", out.String())
+			}
+		})
+	}
+}
+
+// TestPackStatsOfStubs checks that statistics count generated and lock
+// files as read, not the notes that replace them in the output.
+func TestPackStatsOfStubs(t *testing.T) {
+	root := t.TempDir()
+	gen := "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage gen\n\ntype Msg struct{}\n"
+	sum := "example.com/a v1.0.0 h1:abc=\nexample.com/a v1.0.0/go.mod h1:def=\n"
+	writeFiles(t, root, map[string]string{"gen/x.pb.go": gen, "go.sum": sum})
+
+	doc, err := Pack(context.Background(), Options{Paths: []string{root}, Stats: true, SkipGit: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range doc.Files {
+		if f.Content != "" || f.Omitted == "" {
+			t.Errorf("%s packed %q, want a stub", f.Path, f.Content)
+		}
+	}
+	want := map[string]struct{ lines, tokens int }{
+		"gen/x.pb.go": {5, analyzer.EstimateTokens(gen)},
+		"go.sum":      {2, analyzer.EstimateTokens(sum)},
+	}
+	for _, f := range doc.Stats.LargestFiles {
+		w := want[f.Path]
+		if f.Lines != w.lines || f.Tokens != w.tokens {
+			t.Errorf("stats of %s = %d lines, %d tokens; want %d, %d", f.Path, f.Lines, f.Tokens, w.lines, w.tokens)
+		}
+		delete(want, f.Path)
+	}
+	if len(want) > 0 {
+		t.Errorf("stats lack %v", want)
+	}
+}
+
+func TestPackFollowImports(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":        "module example.com/m\n",
+		"README.md":     "# m\n",
+		"a/a.go":        "package a\n\nimport \"example.com/m/b\"\n",
+		"b/b.go":        "package b\n\nimport \"example.com/m/c\"\n",
+		"b/notes.txt":   "not go\n",
+		"c/c.go":        "package c\n",
+		"d/d.go":        "package d\n\nimport \"example.com/m/a\"\n",
+		"vendor/v/v.go": "package v\n",
+		"nested/go.mod": "module example.com/nested\n",
+		"nested/n.go":   "package n\n\nimport \"example.com/m/a\"\n",
+	})
+	tests := []struct {
+		reverse bool
+		want    string
+	}{
+		{false, "a/a.go b/b.go c/c.go"},
+		{true, "a/a.go b/b.go c/c.go d/d.go"},
+	}
+	for _, tt := range tests {
+		doc, err := Pack(context.Background(), Options{Paths: []string{root}, FollowImports: []string{"a"}, ReverseDeps: tt.reverse, SkipGit: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, f := range doc.Files {
+			paths = append(paths, f.Path)
+		}
+		if got := strings.Join(paths, " "); got != tt.want {
+			t.Errorf("FollowImports a (reverse %v) packed %s, want %s", tt.reverse, got, tt.want)
+		}
+	}
+}
+
+// TestPackLineNumbers checks that the numbers rendered for packed lines are
+// those of the original file after compression removed lines and after the
+// file was cut at MaxFileSize.
+func TestPackLineNumbers(t *testing.T) {
+	root := t.TempDir()
+	var long strings.Builder
+	for i := 1; i <= 40; i++ {
+		fmt.Fprintf(&long, "# comment %d\nvalue_%d = %d  # trailing\n", i, i, i)
+	}
+	files := map[string]string{
+		"main.go": "// Package main is an example.\npackage main\n\n/*\nBlock comment.\n*/\nimport \"fmt\"\n\n// main prints.\nfunc main() {\n\tfmt.Println(\"hi\") // greet\n}\n",
+		"long.py": long.String(),
+		"a.txt":   "one\ntwo\n\nfour\n",
+	}
+	writeFiles(t, root, files)
+
+	doc, err := Pack(context.Background(), Options{
+		Paths:       []string{root},
+		Compress:    CompressComments,
+		LineNumbers: true,
+		MaxFileSize: 256,
+		SkipGit:     true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, f := range doc.Files {
+		if len(f.LineRanges) == 0 {
+			t.Errorf("%s has no line ranges", f.Path)
+			continue
+		}
+		seen[f.Path] = true
+		if f.Path == "long.py" && !f.Truncated {
+			t.Errorf("long.py not truncated at MaxFileSize")
+		}
+		src := strings.Split(files[f.Path], "\n")
+		last := 0
+		for _, line := range strings.Split(strings.TrimSuffix(renderer.NumberLines(f.Content, f.LineRanges), "\n"), "\n") {
+			num, text, _ := strings.Cut(line, " | ")
+			n, err := strconv.Atoi(strings.TrimSpace(num))
+			if err != nil || n <= last || n > len(src) {
+				t.Errorf("%s: bad line number in %q after line %d", f.Path, line, last)
+				break
+			}
+			last = n
+			// Compression may cut a trailing comment and truncation the
+			// last line, but what is left starts the original line.
+			if !strings.HasPrefix(src[n-1], strings.TrimRight(text, " \t")) {
+				t.Errorf("%s: line %d packed as %q, is %q", f.Path, n, text, src[n-1])
+			}
+		}
+	}
+	for name := range files {
+		if !seen[name] {
+			t.Errorf("%s not packed", name)
+		}
+	}
+}
+
+// TestPackFiles checks that listed files still obey the excludes of their
+// parent directories and that missing ones are reported.
+func TestPackFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.go":          "package main\n",
+		"vendor/dep/a.go":  "package dep\n",
+		"internal/x/b.go":  "package x\n",
+		"internal/x/c.txt": "c\n",
+	})
+	var warned []string
+	opts := Options{
+		Root:      root,
+		Files:     []string{"main.go", "vendor/dep/a.go", "internal/x", "gone.go"},
+		Exclude:   []string{"vendor", "*.txt"},
+		SkipGit:   true,
+		OnWarning: func(path string, _ error) { warned = append(warned, path) },
+	}
+	doc, err := Pack(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, f := range doc.Files {
+		paths = append(paths, f.Path)
+	}
+	if got, want := strings.Join(paths, " "), "internal/x/b.go main.go"; got != want {
+		t.Errorf("files = %s, want %s", got, want)
+	}
+	if strings.Join(warned, " ") != "gone.go" {
+		t.Errorf("warnings for %v, want gone.go", warned)
+	}
+	if got := doc.Summary.Excluded[RuleFilesFrom]; got != 1 {
+		t.Errorf("Summary.Excluded[%s] = %d, want 1 in %v", RuleFilesFrom, got, doc.Summary.Excluded)
+	}
+
+	ex, err := Explain(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := map[string]string{}
+	for _, d := range ex.Decisions {
+		rules[d.Path] = d.Decision + " " + d.Rule
+	}
+	for path, want := range map[string]string{
+		"vendor/dep/a.go": "excluded " + RuleParent,
+		"gone.go":         "excluded " + RuleFilesFrom,
+	} {
+		if rules[path] != want {
+			t.Errorf("decision for %s = %q, want %q", path, rules[path], want)
+		}
+	}
+}
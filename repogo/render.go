@@ -0,0 +1,80 @@
+package repogo
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/renderer"
+)
+
+// Renderer writes a document in one output format.
+type Renderer interface {
+	Render(w io.Writer, doc *OutputDoc) error
+}
+
+// RendererFunc adapts an ordinary function to the Renderer interface.
+type RendererFunc func(w io.Writer, doc *OutputDoc) error
+
+// Render calls f(w, doc).
+func (f RendererFunc) Render(w io.Writer, doc *OutputDoc) error {
+	return f(w, doc)
+}
+
+// Built-in renderers.
+var (
+	Markdown Renderer = RendererFunc(func(w io.Writer, doc *OutputDoc) error {
+		renderer.RenderMarkdown(w, *doc)
+		return nil
+	})
+	JSON Renderer = RendererFunc(func(w io.Writer, doc *OutputDoc) error {
+		return renderer.RenderJSON(w, *doc)
+	})
+)
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = map[string]Renderer{
+		"markdown": Markdown,
+		"json":     JSON,
+	}
+)
+
+// RegisterRenderer makes r available under name (case-insensitive) to
+// Render and the -format flag, replacing any renderer of that name.
+func RegisterRenderer(name string, r Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers[strings.ToLower(name)] = r
+}
+
+// LookupRenderer returns the renderer registered under name.
+func LookupRenderer(name string) (Renderer, bool) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	r, ok := renderers[strings.ToLower(name)]
+	return r, ok
+}
+
+// Formats returns the names of all registered renderers, sorted.
+func Formats() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render writes doc to w using the renderer registered under format.
+func Render(w io.Writer, format string, doc *OutputDoc) error {
+	r, ok := LookupRenderer(format)
+	if !ok {
+		return fmt.Errorf("unknown format %q (available: %s)", format, strings.Join(Formats(), ", "))
+	}
+	return r.Render(w, doc)
+}
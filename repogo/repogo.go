@@ -0,0 +1,280 @@
+// Package repogo packs a repository's files, structure and Git metadata into a
+// single document for LLM context, documentation or analysis. It is the
+// library behind the repogo command and can be embedded in other Go programs:
+//
+//	doc, err := repogo.Pack(ctx, repogo.Options{
+//		Paths:     []string{"."},
+//		Exclude:   []string{"vendor", "*_test.go"},
+//		MaxTokens: 50000,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	return repogo.Render(os.Stdout, "markdown", doc)
+package repogo
+
+import (
+	"time"
+
+	"github.com/AndersonTsaiTW/RepoGo/internal/analyzer"
+	"github.com/AndersonTsaiTW/RepoGo/internal/models"
+	"github.com/AndersonTsaiTW/RepoGo/internal/scanner"
+)
+
+// Document types shared with the renderers.
+type (
+	OutputDoc  = models.OutputDoc
+	FileEntry  = models.FileEntry
+	GitInfo    = models.GitInfo
+	Summary    = models.Summary
+	Stats      = models.Stats
+	GroupStats = models.GroupStats
+	FileStats  = models.FileStats
+	TreeNode   = models.TreeNode
+)
+
+// Explain mode types; see Explain.
+type (
+	Decision    = models.Decision
+	Explanation = models.Explanation
+)
+
+// Decisions reported in Decision.Decision.
+const (
+	DecisionIncluded = models.DecisionIncluded
+	DecisionStubbed  = models.DecisionStubbed
+	DecisionOmitted  = models.DecisionOmitted
+	DecisionExcluded = models.DecisionExcluded
+)
+
+// Rules reported in Decision.Rule.
+const (
+	RuleExclude      = scanner.RuleExclude    // an Exclude glob
+	RulePreset       = scanner.RulePreset     // an exclude of one of Presets
+	RuleInclude      = scanner.RuleInclude    // no Include glob matches
+	RuleFilter       = scanner.RuleFilter     // one of Filters
+	RuleLimit        = scanner.RuleLimit      // NewerThan, OlderThan, MinSize, MaxSize or MaxDepth
+	RuleSymlink      = scanner.RuleSymlink    // the Symlinks policy, or a broken link
+	RuleParent       = scanner.RuleParent     // inside an excluded directory
+	RuleNestedGit    = "nested-git"           // the .git of a nested repository
+	RuleSubmodule    = "submodule"            // a git submodule, per Submodules
+	RuleFilesFrom    = "files-from"           // a listed path that cannot be read
+	RuleGrep         = "grep"                 // Grep, or the snippets packed for it
+	RuleGenerated    = "generated"            // generated, minified or vendored, per Generated
+	RuleDependencies = "dependencies"         // a lock file summarized in Dependencies
+	RuleBinary       = "binary"               // binary content
+	RuleUnreadable   = scanner.RuleUnreadable // an error reading the file
+	RuleMaxFileSize  = "max-file-size"        // content cut at MaxFileSize
+	RuleDedupe       = "dedupe"               // a duplicate, per Dedupe
+	RuleBudget       = "budget"               // MaxTokens
+)
+
+// Language describes how a language is recognized; see RegisterLanguage.
+type Language = analyzer.Language
+
+// TreeOptions controls how the Structure section is rendered.
+type TreeOptions = scanner.TreeOptions
+
+// File orders for Options.Sort.
+const (
+	SortPath  = "path"
+	SortChurn = "churn"
+)
+
+// Symlink policies for Options.Symlinks.
+const (
+	SymlinksFollow = scanner.SymlinksFollow
+	SymlinksSkip   = scanner.SymlinksSkip
+	SymlinksRecord = scanner.SymlinksRecord
+)
+
+// Policies for generated, minified and vendored files (Options.Generated).
+const (
+	GeneratedStub    = "stub"
+	GeneratedSkip    = "skip"
+	GeneratedInclude = "include"
+)
+
+// Compression levels for Options.Compress, each including the previous one.
+const (
+	CompressNone       = analyzer.CompressNone
+	CompressWhitespace = analyzer.CompressWhitespace
+	CompressComments   = analyzer.CompressComments
+	CompressIndent     = analyzer.CompressIndent
+)
+
+// Submodule modes for Options.Submodules.
+const (
+	SubmodulesStub    = "stub"
+	SubmodulesInclude = "include"
+	SubmodulesSkip    = "skip"
+)
+
+// DefaultMaxFileSize is the per-file read limit used when Options.MaxFileSize is zero.
+const DefaultMaxFileSize = 16 * 1024
+
+// Options configures Pack. The zero value packs the current directory.
+type Options struct {
+	// Paths are the files and directories to pack (default ".").
+	Paths []string
+	// Files, when not nil, replaces Paths with a list of paths relative to
+	// Root (default "."), such as the output of `git diff --name-only`.
+	// Listed files are packed without walking the tree, but still pass the
+	// globs, filters and limits, including excludes of their parent
+	// directories; listed directories are walked. Paths that cannot be
+	// found are reported to OnWarning and counted in Summary.Excluded
+	// under RuleFilesFrom.
+	Files []string
+	// Root is the directory paths are reported relative to. When empty it
+	// is the first directory in Paths, or the common parent of the files.
+	Root string
+
+	// Include and Exclude are glob patterns (*, ?, [class]) matched against
+	// the relative path and the base name. Excluded directories are pruned.
+	Include []string
+	Exclude []string
+	// Presets adds the excludes of built-in ecosystem presets such as "go"
+	// or "node"; "auto" selects those whose manifests exist in the root.
+	Presets []string
+	// FollowImports restricts the scan to the Go packages named here
+	// (import paths, package directories or .go files) and every package
+	// of the same module they import. ReverseDeps also adds the packages
+	// that import them.
+	FollowImports []string
+	ReverseDeps   bool
+	// ImportGraph attaches the module's package dependency graph, limited
+	// to the followed packages when FollowImports is set.
+	ImportGraph bool
+
+	// Grep keeps only files whose content matches these regular
+	// expressions: any of them, or all of them when GrepAll is set.
+	Grep    []string
+	GrepAll bool
+	// Snippets replaces the content of files selected by Grep with the
+	// matching lines and ContextLines lines around each match (none when
+	// negative).
+	Snippets     bool
+	ContextLines int
+	// Filters are consulted for every path that passes the globs.
+	Filters []Filter
+	// Generated selects what happens to files detected as generated,
+	// minified or vendored (by "Code generated" headers, linguist
+	// attributes in .gitattributes, or long lines with little whitespace):
+	// GeneratedStub (the default) lists them with the reason instead of
+	// their content, GeneratedSkip leaves them out and GeneratedInclude
+	// packs them normally. Summary.Skipped counts them by kind.
+	Generated string
+	// Submodules selects how git submodules below the root are scanned:
+	// SubmodulesStub (the default) shows each as a single structure entry
+	// with its pinned commit, SubmodulesInclude scans their files like any
+	// other directory and SubmodulesSkip leaves them out. The .git entries
+	// of submodules and nested repositories are skipped unless NestedGit
+	// is set.
+	Submodules string
+	NestedGit  bool
+	// Symlinks selects how symbolic links are treated: SymlinksFollow (the
+	// default) reads linked files and traverses linked directories,
+	// skipping cycles; SymlinksSkip ignores them; SymlinksRecord lists each
+	// link and its target without reading it. Links leading outside the
+	// root are refused with a warning unless SymlinksOutside is set.
+	Symlinks        string
+	SymlinksOutside bool
+	// NewerThan and OlderThan keep only files last modified after, or not
+	// after, the given times; MinSize and MaxSize only files of at least,
+	// or at most, that many bytes, excluding larger files rather than
+	// truncating them. MaxDepth limits the directory levels scanned below
+	// the root (1 = only its own files). Zero values set no limit.
+	// Summary.Excluded counts the paths they leave out by reason.
+	NewerThan time.Time
+	OlderThan time.Time
+	MinSize   int64
+	MaxSize   int64
+	MaxDepth  int
+
+	// Dedupe packs the content of identical files once and lists later
+	// copies as references to the first. With a DedupeThreshold between 0
+	// and 1, files whose share of common lines reaches it are packed as a
+	// diff against the earlier file when that is smaller.
+	Dedupe          bool
+	DedupeThreshold float64
+	// Compress shrinks packed contents in files of a recognized language:
+	// CompressWhitespace trims trailing whitespace and squeezes blank
+	// lines, CompressComments also strips comments (keeping strings intact)
+	// and CompressIndent also reduces indentation to one space per level.
+	// The default, CompressNone, packs files verbatim.
+	Compress string
+
+	// LineNumbers records which lines of the original file each packed
+	// content covers (FileEntry.LineRanges), so that renderers can number
+	// lines as they appear in the file even after compression.
+	LineNumbers bool
+
+	// MaxFileSize is the number of bytes read per file (0 = DefaultMaxFileSize).
+	MaxFileSize int
+	// MaxTokens stops packing contents once the estimated total would be
+	// exceeded (0 = no limit).
+	MaxTokens int
+
+	// Query orders files by BM25 relevance of their paths, identifiers and
+	// contents to this text, so that MaxTokens keeps the most relevant ones.
+	Query string
+
+	// History attaches each file's last commit and its number of commits
+	// and authors since HistorySince (the whole history when zero).
+	History      bool
+	HistorySince time.Time
+	// Sort orders the packed files: SortPath (the default) or SortChurn,
+	// most commits first. It has no effect when Query is set.
+	Sort string
+
+	// Stats attaches a per-language and per-directory breakdown.
+	Stats bool
+	// Tree controls the rendering of the Structure section.
+	Tree TreeOptions
+	// Log attaches the last Log commits (0 = none). LogStats adds per-file
+	// line counts; LogPackedOnly keeps only commits touching packed files.
+	Log           int
+	LogStats      bool
+	LogPackedOnly bool
+	// SkipDependencies disables the Dependencies section and packs lock
+	// files verbatim instead of summarizing them.
+	SkipDependencies bool
+	// SkipGit leaves OutputDoc.Git empty instead of querying the repository.
+	SkipGit bool
+
+	// OnFile, when set, is called for each file as it is added to the
+	// document, in output order. Returning an error aborts Pack.
+	OnFile func(FileEntry) error
+	// OnWarning, when set, receives problems with individual paths that
+	// do not abort the scan, such as unreadable directories.
+	OnWarning func(path string, err error)
+
+	// onDecision receives the decision taken for each path; see Explain.
+	onDecision func(Decision)
+}
+
+// Filter decides whether a path takes part in the scan. relPath is
+// slash-separated and relative to the root; rejecting a directory skips
+// everything below it.
+type Filter interface {
+	Keep(relPath string, isDir bool) bool
+}
+
+// FilterFunc adapts an ordinary function to the Filter interface.
+type FilterFunc func(relPath string, isDir bool) bool
+
+// Keep calls f(relPath, isDir).
+func (f FilterFunc) Keep(relPath string, isDir bool) bool {
+	return f(relPath, isDir)
+}
+
+// RegisterLanguage adds or extends a language definition used for fences,
+// line counts and statistics.
+func RegisterLanguage(l Language) error {
+	return analyzer.RegisterLanguage(l)
+}
+
+// LoadLanguages registers the language definitions in a JSON file.
+func LoadLanguages(file string) error {
+	return analyzer.LoadLanguages(file)
+}